@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+const (
+	defaultActivityPageSize = 50
+	maxActivityPageSize     = 200
+)
+
+// GetTaskHistory returns the history of a task (also once deleted) to project members
+func GetTaskHistory(c *gin.Context) {
+	tidStr := c.Param("taskId")
+	tid64, err := strconv.ParseUint(tidStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+	taskID := uint(tid64)
+
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var task models.Task
+	if err := initializers.DB.Unscoped().First(&task, taskID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+
+	isMember, err := IsProjectMember(task.ProjectID, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+		return
+	}
+
+	var history []models.TaskActivity
+	if err := initializers.DB.
+		Where("task_id = ?", taskID).
+		Preload("Actor").
+		Order("created_at, id").
+		Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// GetProjectActivity returns the activity feed of a project, newest first.
+// Query: page, per_page, actor_id, type.
+func GetProjectActivity(c *gin.Context) {
+	pidStr := c.Param("projectId")
+	pid64, err := strconv.ParseUint(pidStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	projectID := uint(pid64)
+
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	isMember, err := IsProjectMember(projectID, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultActivityPageSize)))
+	if err != nil || perPage < 1 || perPage > maxActivityPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid per_page"})
+		return
+	}
+
+	q := initializers.DB.Model(&models.TaskActivity{}).Where("project_id = ?", projectID)
+	if actorStr := c.Query("actor_id"); actorStr != "" {
+		actorID, err := strconv.ParseUint(actorStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor id"})
+			return
+		}
+		q = q.Where("actor_id = ?", uint(actorID))
+	}
+	if typ := c.Query("type"); typ != "" {
+		q = q.Where("type = ?", typ)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+
+	var activity []models.TaskActivity
+	if err := q.
+		Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&activity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load activity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"activity": activity,
+		"page":     page,
+		"per_page": perPage,
+		"total":    total,
+	})
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// formatActivityValue turns a column value into the text stored in the history
+func formatActivityValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case *string:
+		if val == nil {
+			return ""
+		}
+		return *val
+	case time.Time:
+		return val.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return val.UTC().Format(time.RFC3339)
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case *uint:
+		if val == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*val), 10)
+	case *int:
+		if val == nil {
+			return ""
+		}
+		return strconv.Itoa(*val)
+	case *float64:
+		if val == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// taskColumnValue returns the current value of a task column, as used in UpdateTask maps
+func taskColumnValue(t models.Task, column string) interface{} {
+	switch column {
+	case "title":
+		return t.Title
+	case "description":
+		return t.Description
+	case "status":
+		return t.Status
+	case "priority":
+		return t.Priority
+	case "due_date":
+		return t.DueDate
+	case "estimate":
+		return t.Estimate
+	case "story_points":
+		return t.StoryPoints
+	case "sprint_id":
+		return t.SprintID
+	case "project_id":
+		return t.ProjectID
+	case "original_estimate":
+		return t.OriginalEstimate
+	case "remaining_estimate":
+		return t.RemainingEstimate
+	case "recurrence":
+		return t.Recurrence
+	case "recurrence_mode":
+		return t.RecurrenceMode
+	default:
+		return nil
+	}
+}
+
+// recordTaskActivity appends one history entry; failures are only logged
+// so that history never blocks the change itself
+func recordTaskActivity(db *gorm.DB, task models.Task, actorID uint, typ, field, oldValue, newValue string) {
+	entry := models.TaskActivity{
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		ActorID:   actorID,
+		Type:      typ,
+		Field:     field,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("could not record activity for task %d: %v", task.ID, err)
+	}
+}
+
+// recordTaskChanges writes one "updated" entry per column of updated that
+// differs from before. Bookkeeping columns (rank, version) are skipped.
+func recordTaskChanges(db *gorm.DB, before models.Task, updated map[string]interface{}, actorID uint) {
+	columns := make([]string, 0, len(updated))
+	for col := range updated {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	for _, col := range columns {
+		if col == "board_rank" || col == "version" {
+			continue
+		}
+		oldValue := formatActivityValue(taskColumnValue(before, col))
+		newValue := formatActivityValue(updated[col])
+		if oldValue == newValue {
+			continue
+		}
+		recordTaskActivity(db, before, actorID, models.ActivityUpdated, col, oldValue, newValue)
+	}
+}
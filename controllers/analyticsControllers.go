@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// analyticsMaxDays bounds the date range of a chart
+const analyticsMaxDays = 366
+
+// flowDay : état du projet à la fin d'une journée, en tâches et en points
+type flowDay struct {
+	Date   time.Time
+	Count  map[string]float64
+	Points map[string]float64
+}
+
+// analyticsQuery is the parsed query of the analytics endpoints
+type analyticsQuery struct {
+	projectID uint
+	sprintID  uint
+	from, to  time.Time // premiers instants des jours inclus
+	unit      string
+}
+
+// fieldChange is one recorded change of a task field (status, sprint_id)
+type fieldChange struct {
+	TaskID    uint
+	OldValue  string
+	NewValue  string
+	CreatedAt time.Time
+}
+
+// fieldReplay walks the history of one field of one task, day by day
+type fieldReplay struct {
+	history []fieldChange
+	cursor  int
+	current string // valeur actuelle, si aucun changement n'est enregistré
+}
+
+// at returns the value of the field at end (days must be asked in order)
+func (r *fieldReplay) at(end time.Time) string {
+	for r.cursor < len(r.history) && r.history[r.cursor].CreatedAt.Before(end) {
+		r.cursor++
+	}
+	switch {
+	case r.cursor > 0:
+		return r.history[r.cursor-1].NewValue
+	case len(r.history) > 0:
+		return r.history[0].OldValue
+	}
+	return r.current
+}
+
+// fieldChanges loads the recorded changes of a task field in a project, per task
+func fieldChanges(projectID uint, field string) (map[uint][]fieldChange, error) {
+	var changes []fieldChange
+	if err := initializers.DB.Model(&models.TaskActivity{}).
+		Select("task_id, old_value, new_value, created_at").
+		Where("project_id = ? AND type = ? AND field = ?", projectID, models.ActivityUpdated, field).
+		Order("created_at").Order("id").
+		Scan(&changes).Error; err != nil {
+		return nil, err
+	}
+	byTask := map[uint][]fieldChange{}
+	for _, ch := range changes {
+		byTask[ch.TaskID] = append(byTask[ch.TaskID], ch)
+	}
+	return byTask, nil
+}
+
+// parseAnalyticsQuery reads from, to (YYYY-MM-DD), unit=count|points and
+// sprint_id. A sprint defaults the range to its dates, otherwise the last 30 days.
+func parseAnalyticsQuery(c *gin.Context) (analyticsQuery, bool) {
+	var q analyticsQuery
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return q, false
+	}
+	q.projectID = projectID
+
+	q.unit = c.DefaultQuery("unit", "count")
+	if q.unit != "count" && q.unit != "points" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unit must be count or points"})
+		return q, false
+	}
+
+	today := truncateDay(time.Now())
+	q.to = today
+	q.from = today.AddDate(0, 0, -29)
+
+	if raw := c.Query("sprint_id"); raw != "" {
+		sid, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint_id"})
+			return q, false
+		}
+		var s models.Sprint
+		if err := initializers.DB.Where("id = ? AND project_id = ?", uint(sid), projectID).First(&s).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "sprint not found"})
+			return q, false
+		}
+		q.sprintID = s.ID
+		switch {
+		case s.StartDate != nil:
+			q.from = truncateDay(*s.StartDate)
+		case s.StartedAt != nil:
+			q.from = truncateDay(*s.StartedAt)
+		default:
+			q.from = truncateDay(s.CreatedAt)
+		}
+		switch {
+		case s.EndDate != nil:
+			q.to = truncateDay(*s.EndDate)
+		case s.ClosedAt != nil:
+			q.to = truncateDay(*s.ClosedAt)
+		}
+	}
+
+	for name, dst := range map[string]*time.Time{"from": &q.from, "to": &q.to} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseInLocation(filterDateLayout, raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " (expected YYYY-MM-DD)"})
+			return q, false
+		}
+		*dst = d
+	}
+	if q.to.Before(q.from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to is before from"})
+		return q, false
+	}
+	if daysBetween(q.from, q.to) >= analyticsMaxDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("range is limited to %d days", analyticsMaxDays)})
+		return q, false
+	}
+	return q, true
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()+12) / 24
+}
+
+// projectFlow returns one snapshot per day from q.from to min(q.to, today),
+// replaying the status history of the project's tasks (cached)
+func projectFlow(q analyticsQuery) ([]flowDay, error) {
+	key := fmt.Sprintf("%s|%s|%d", q.from.Format(filterDateLayout), q.to.Format(filterDateLayout), q.sprintID)
+	if days, ok := analytics.get(q.projectID, key); ok {
+		return days, nil
+	}
+
+	// tâches supprimées incluses : elles comptaient avant leur suppression
+	tx := initializers.DB.Unscoped().
+		Select("id", "status", "sprint_id", "story_points", "created_at", "deleted_at").
+		Where("project_id = ?", q.projectID)
+
+	// sprint : l'appartenance est rejouée depuis l'historique de sprint_id,
+	// une tâche reportée à la clôture reste dans le sprint jusque-là
+	var sprintChanges map[uint][]fieldChange
+	if q.sprintID != 0 {
+		var err error
+		if sprintChanges, err = fieldChanges(q.projectID, "sprint_id"); err != nil {
+			return nil, err
+		}
+		sprint := formatActivityValue(q.sprintID)
+		ids := []uint{}
+		for id, history := range sprintChanges {
+			for _, ch := range history {
+				if ch.OldValue == sprint || ch.NewValue == sprint {
+					ids = append(ids, id)
+					break
+				}
+			}
+		}
+		if len(ids) > 0 {
+			tx = tx.Where("sprint_id = ? OR id IN ?", q.sprintID, ids)
+		} else {
+			tx = tx.Where("sprint_id = ?", q.sprintID)
+		}
+	}
+	var tasks []models.Task
+	if err := tx.Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	statusChanges, err := fieldChanges(q.projectID, "status")
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]fieldReplay, len(tasks))
+	sprints := make([]fieldReplay, len(tasks))
+	for i, t := range tasks {
+		statuses[i] = fieldReplay{history: statusChanges[t.ID], current: t.Status}
+		sprints[i] = fieldReplay{history: sprintChanges[t.ID], current: formatActivityValue(t.SprintID)}
+	}
+
+	last := q.to
+	if today := truncateDay(time.Now()); last.After(today) {
+		last = today
+	}
+	var days []flowDay
+	for day := q.from; !day.After(last); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		snap := flowDay{
+			Date:   day,
+			Count:  map[string]float64{models.TaskStatusTodo: 0, models.TaskStatusDoing: 0, models.TaskStatusDone: 0},
+			Points: map[string]float64{models.TaskStatusTodo: 0, models.TaskStatusDoing: 0, models.TaskStatusDone: 0},
+		}
+		for i, t := range tasks {
+			if !t.CreatedAt.Before(end) || (t.DeletedAt.Valid && t.DeletedAt.Time.Before(end)) {
+				continue
+			}
+			status := statuses[i].at(end)
+			if q.sprintID != 0 && sprints[i].at(end) != formatActivityValue(q.sprintID) {
+				continue
+			}
+			if status != models.TaskStatusDone && status != models.TaskStatusDoing {
+				status = models.TaskStatusTodo
+			}
+			snap.Count[status]++
+			if t.StoryPoints != nil {
+				snap.Points[status] += *t.StoryPoints
+			}
+		}
+		days = append(days, snap)
+	}
+
+	analytics.put(q.projectID, key, days)
+	return days, nil
+}
+
+// values picks counts or points
+func (d flowDay) values(unit string) map[string]float64 {
+	if unit == "points" {
+		return d.Points
+	}
+	return d.Count
+}
+
+func (d flowDay) total(unit string) float64 {
+	v := d.values(unit)
+	return v[models.TaskStatusTodo] + v[models.TaskStatusDoing] + v[models.TaskStatusDone]
+}
+
+func loadProjectFlow(c *gin.Context) (analyticsQuery, []flowDay, bool) {
+	q, ok := parseAnalyticsQuery(c)
+	if !ok {
+		return q, nil, false
+	}
+	days, err := projectFlow(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not compute analytics"})
+		return q, nil, false
+	}
+	return q, days, true
+}
+
+func analyticsResponse(q analyticsQuery, series interface{}) gin.H {
+	out := gin.H{
+		"from":   q.from.Format(filterDateLayout),
+		"to":     q.to.Format(filterDateLayout),
+		"unit":   q.unit,
+		"series": series,
+	}
+	if q.sprintID != 0 {
+		out["sprint_id"] = q.sprintID
+	}
+	return out
+}
+
+// GetProjectBurndown : travail restant par jour et ligne idéale jusqu'à `to`
+func GetProjectBurndown(c *gin.Context) {
+	q, days, ok := loadProjectFlow(c)
+	if !ok {
+		return
+	}
+	type point struct {
+		Date      string   `json:"date"`
+		Remaining *float64 `json:"remaining"` // null pour les jours à venir
+		Ideal     float64  `json:"ideal"`
+	}
+	start := 0.0
+	if len(days) > 0 {
+		start = days[0].total(q.unit) - days[0].values(q.unit)[models.TaskStatusDone]
+	}
+	n := daysBetween(q.from, q.to)
+	series := make([]point, 0, n+1)
+	for i := 0; i <= n; i++ {
+		p := point{Date: q.from.AddDate(0, 0, i).Format(filterDateLayout), Ideal: start}
+		if n > 0 {
+			p.Ideal = round1(start * float64(n-i) / float64(n))
+		}
+		if i < len(days) {
+			remaining := days[i].total(q.unit) - days[i].values(q.unit)[models.TaskStatusDone]
+			p.Remaining = &remaining
+		}
+		series = append(series, p)
+	}
+	c.JSON(http.StatusOK, analyticsResponse(q, series))
+}
+
+// GetProjectBurnup : périmètre total et travail terminé par jour
+func GetProjectBurnup(c *gin.Context) {
+	q, days, ok := loadProjectFlow(c)
+	if !ok {
+		return
+	}
+	type point struct {
+		Date      string  `json:"date"`
+		Scope     float64 `json:"scope"`
+		Completed float64 `json:"completed"`
+	}
+	series := make([]point, 0, len(days))
+	for _, d := range days {
+		series = append(series, point{
+			Date:      d.Date.Format(filterDateLayout),
+			Scope:     d.total(q.unit),
+			Completed: d.values(q.unit)[models.TaskStatusDone],
+		})
+	}
+	c.JSON(http.StatusOK, analyticsResponse(q, series))
+}
+
+// GetProjectCFD : diagramme de flux cumulé, tâches (ou points) par statut et par jour
+func GetProjectCFD(c *gin.Context) {
+	q, days, ok := loadProjectFlow(c)
+	if !ok {
+		return
+	}
+	type point struct {
+		Date  string  `json:"date"`
+		Todo  float64 `json:"todo"`
+		Doing float64 `json:"doing"`
+		Done  float64 `json:"done"`
+	}
+	series := make([]point, 0, len(days))
+	for _, d := range days {
+		v := d.values(q.unit)
+		series = append(series, point{
+			Date:  d.Date.Format(filterDateLayout),
+			Todo:  v[models.TaskStatusTodo],
+			Doing: v[models.TaskStatusDoing],
+			Done:  v[models.TaskStatusDone],
+		})
+	}
+	c.JSON(http.StatusOK, analyticsResponse(q, series))
+}
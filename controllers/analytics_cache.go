@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+)
+
+// analyticsTTL bounds how long a series is reused: "today" keeps moving even
+// when nothing is published for the project
+const analyticsTTL = 5 * time.Minute
+
+// analyticsMaxEntries caps the cached ranges per project
+const analyticsMaxEntries = 32
+
+type analyticsEntry struct {
+	days []flowDay
+	at   time.Time
+}
+
+// analyticsCache keeps the daily snapshots per project and range; any event
+// of a project drops its entries
+type analyticsCache struct {
+	mu       sync.Mutex
+	projects map[uint]map[string]analyticsEntry
+}
+
+var analytics = &analyticsCache{projects: map[uint]map[string]analyticsEntry{}}
+
+func (a *analyticsCache) get(projectID uint, key string) ([]flowDay, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e, ok := a.projects[projectID][key]
+	if !ok || time.Since(e.at) > analyticsTTL {
+		return nil, false
+	}
+	return e.days, true
+}
+
+func (a *analyticsCache) put(projectID uint, key string, days []flowDay) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entries := a.projects[projectID]
+	if entries == nil || len(entries) >= analyticsMaxEntries {
+		entries = map[string]analyticsEntry{}
+		a.projects[projectID] = entries
+	}
+	entries[key] = analyticsEntry{days: days, at: time.Now()}
+}
+
+func (a *analyticsCache) invalidate(projectID uint) {
+	a.mu.Lock()
+	delete(a.projects, projectID)
+	a.mu.Unlock()
+}
+
+// RegisterAnalyticsCache drops cached analytics of a project on each of its events
+func RegisterAnalyticsCache(hub *realtime.Hub) {
+	hub.Listen(func(ev realtime.Event) {
+		analytics.invalidate(ev.ProjectID)
+	})
+}
@@ -0,0 +1,461 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+)
+
+// maxBulkTasks bounds the number of tasks of one bulk request
+const maxBulkTasks = 500
+
+// opérations possibles ; les étiquettes n'existent pas dans ce modèle
+const (
+	bulkSetStatus   = "set_status"
+	bulkSetPriority = "set_priority"
+	bulkSetDueDate  = "set_due_date"
+	bulkAssign      = "assign"
+	bulkUnassign    = "unassign"
+	bulkSetSprint   = "set_sprint"
+	bulkDelete      = "delete"
+)
+
+var taskPriorities = map[string]bool{
+	models.TaskPriorityLow:    true,
+	models.TaskPriorityMedium: true,
+	models.TaskPriorityHigh:   true,
+}
+
+// bulkPayload : une opération appliquée à une liste de tâches
+type bulkPayload struct {
+	TaskIDs   []uint `json:"task_ids" binding:"required"`
+	Operation string `json:"operation" binding:"required"`
+
+	Status   string     `json:"status"`
+	Priority string     `json:"priority"`
+	DueDate  *time.Time `json:"due_date"` // null = plus d'échéance
+	UserID   uint       `json:"user_id"`
+	SprintID uint       `json:"sprint_id"` // 0 = retour au backlog
+
+	// versions attendues par tâche, comme If-Match sur PUT /tasks/:taskId
+	// (obligatoires sauf pour assign / unassign)
+	Versions map[string]uint `json:"versions"`
+	// true : rien n'est appliqué si une tâche est refusée
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+// bulkResult is the outcome of the operation for one task
+type bulkResult struct {
+	TaskID uint   `json:"task_id"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// bulkChange is an applied change, whose events and notifications are sent
+// once the transaction is committed
+type bulkChange struct {
+	before  models.Task
+	updated map[string]interface{}
+	added   bool // assign / unassign : le lien a vraiment changé
+}
+
+// validateBulkPayload checks the value of the operation once for the batch
+func validateBulkPayload(projectID uint, body bulkPayload) error {
+	switch body.Operation {
+	case bulkSetStatus:
+		if !boardStatuses[body.Status] {
+			return errors.New("invalid status")
+		}
+	case bulkSetPriority:
+		if !taskPriorities[body.Priority] {
+			return errors.New("invalid priority")
+		}
+	case bulkSetDueDate, bulkDelete:
+	case bulkAssign, bulkUnassign:
+		if body.UserID == 0 {
+			return errors.New("user_id is required")
+		}
+		if body.Operation == bulkAssign {
+			isMember, err := IsProjectMember(projectID, body.UserID)
+			if err != nil || !isMember {
+				return errors.New("target user not a project member")
+			}
+		}
+	case bulkSetSprint:
+		return validateSprintTask(projectID, body.SprintID)
+	default:
+		return errors.New("operation must be set_status, set_priority, set_due_date, assign, unassign, set_sprint or delete")
+	}
+	return nil
+}
+
+// BulkUpdateTasks applies one operation to many tasks of a project (members).
+// Each task follows the rules of the single-task endpoint: changing fields or
+// deleting needs the creator or the project owner, (un)assigning any member.
+// Everything runs in one transaction; the response reports every task.
+func BulkUpdateTasks(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+
+	var body bulkPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ids := make([]uint, 0, len(body.TaskIDs))
+	seen := map[uint]bool{}
+	for _, id := range body.TaskIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_ids is empty"})
+		return
+	}
+	if len(ids) > maxBulkTasks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many tasks (max " + strconv.Itoa(maxBulkTasks) + ")"})
+		return
+	}
+	if err := validateBulkPayload(projectID, body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// comme If-Match sur PUT/DELETE /tasks/:taskId : version obligatoire,
+	// sauf pour (dés)assigner qui ne touche pas aux champs de la tâche
+	if body.Operation != bulkAssign && body.Operation != bulkUnassign {
+		missing := []uint{}
+		for _, id := range ids {
+			if _, ok := bulkVersion(body, id); !ok {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "missing version for some tasks", "task_ids": missing})
+			return
+		}
+	}
+
+	isOwner, err := IsProjectOwner(projectID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+
+	var tasks []models.Task
+	if err := initializers.DB.Where("project_id = ? AND id IN ?", projectID, ids).Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	byID := map[uint]models.Task{}
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	// permissions et versions, tâche par tâche, avant toute écriture
+	results := make([]bulkResult, len(ids))
+	accepted := []models.Task{}
+	for i, id := range ids {
+		results[i].TaskID = id
+		task, found := byID[id]
+		switch {
+		case !found:
+			results[i].Error = "task not found"
+		case body.Operation != bulkAssign && body.Operation != bulkUnassign && task.CreatorID != userID && !isOwner:
+			results[i].Error = "only creator or owner can update task"
+			if body.Operation == bulkDelete {
+				results[i].Error = "only creator or owner can delete task"
+			}
+		default:
+			if v, ok := bulkVersion(body, id); ok && v != task.Version {
+				results[i].Error = "version conflict"
+				continue
+			}
+			results[i].OK = true
+			accepted = append(accepted, task)
+		}
+	}
+	refused := len(ids) - len(accepted)
+
+	// limites WIP : le lot entier entre dans la colonne
+	var wipWarnings []wipViolation
+	if body.Operation == bulkSetStatus {
+		moving := []uint{}
+		for _, t := range accepted {
+			if t.Status != body.Status {
+				moving = append(moving, t.ID)
+			}
+		}
+		mode, violations, err := checkBulkWipLimits(projectID, body.Status, moving)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		if len(violations) > 0 && mode == models.WipModeBlock {
+			c.JSON(http.StatusConflict, gin.H{"error": "WIP limit exceeded", "violations": violations})
+			return
+		}
+		wipWarnings = violations
+	}
+	// assign : limites WIP de l'assigné, colonne par colonne
+	if body.Operation == bulkAssign && len(accepted) > 0 {
+		acceptedIDs := make([]uint, len(accepted))
+		for i, t := range accepted {
+			acceptedIDs[i] = t.ID
+		}
+		var already []uint
+		if err := initializers.DB.Model(&models.TaskAssignee{}).
+			Where("user_id = ? AND task_id IN ?", body.UserID, acceptedIDs).
+			Pluck("task_id", &already).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		assigned := map[uint]bool{}
+		for _, id := range already {
+			assigned[id] = true
+		}
+		perStatus := map[string]int{}
+		for _, t := range accepted {
+			if !assigned[t.ID] {
+				perStatus[t.Status]++
+			}
+		}
+		for status, n := range perStatus {
+			mode, violations, err := checkAddedWipLimits(projectID, status, nil, 0, map[uint]int{body.UserID: n})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+				return
+			}
+			if len(violations) > 0 && mode == models.WipModeBlock {
+				c.JSON(http.StatusConflict, gin.H{"error": "WIP limit exceeded", "violations": violations})
+				return
+			}
+			wipWarnings = append(wipWarnings, violations...)
+		}
+	}
+
+	if body.AllOrNothing && refused > 0 {
+		for i := range results {
+			if results[i].OK {
+				results[i].OK = false
+				results[i].Error = "not applied: other tasks were refused"
+			}
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "some tasks were refused, nothing applied", "results": results})
+		return
+	}
+
+	index := map[uint]int{}
+	for i, r := range results {
+		index[r.TaskID] = i
+	}
+
+	var changes []bulkChange
+	conflicts := 0
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for _, task := range accepted {
+			change, err := applyBulkOperation(tx, task, body, userID)
+			if errors.Is(err, errVersionConflict) {
+				// la tâche a changé depuis la lecture : refusée à son tour
+				i := index[task.ID]
+				results[i].OK = false
+				results[i].Error = "version conflict"
+				conflicts++
+				if body.AllOrNothing {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	if errors.Is(err, errVersionConflict) {
+		for i := range results {
+			if results[i].OK {
+				results[i].OK = false
+				results[i].Error = "not applied: other tasks were refused"
+			}
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "some tasks were refused, nothing applied", "results": results})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not apply bulk operation"})
+		return
+	}
+
+	for _, ch := range changes {
+		afterBulkChange(ch, body, userID)
+	}
+
+	resp := gin.H{
+		"operation": body.Operation,
+		"succeeded": len(accepted) - conflicts,
+		"failed":    refused + conflicts,
+		"results":   results,
+	}
+	if len(wipWarnings) > 0 {
+		resp["wip_warnings"] = wipWarnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// bulkVersion returns the version expected for a task, if one was supplied
+func bulkVersion(body bulkPayload, id uint) (uint, bool) {
+	v, ok := body.Versions[strconv.FormatUint(uint64(id), 10)]
+	return v, ok
+}
+
+// applyBulkOperation writes the operation for one task inside the transaction.
+// With an expected version the write is conditional on it (errVersionConflict
+// when the task changed since it was read).
+func applyBulkOperation(tx *gorm.DB, task models.Task, body bulkPayload, userID uint) (bulkChange, error) {
+	ch := bulkChange{before: task}
+	q := tx.Where("id = ?", task.ID)
+	v, versioned := bulkVersion(body, task.ID)
+	if versioned {
+		q = tx.Where("id = ? AND version = ?", task.ID, v)
+	}
+
+	switch body.Operation {
+	case bulkAssign:
+		ass := models.TaskAssignee{TaskID: task.ID, UserID: body.UserID}
+		res := tx.Where("task_id = ? AND user_id = ?", task.ID, body.UserID).FirstOrCreate(&ass)
+		if res.Error != nil {
+			return ch, res.Error
+		}
+		if ch.added = res.RowsAffected > 0; ch.added {
+			if err := bumpTaskVersion(tx, task.ID); err != nil {
+				return ch, err
+			}
+			recordTaskActivity(tx, task, userID, models.ActivityAssigned, "assignee", "", formatActivityValue(body.UserID))
+		}
+		return ch, nil
+
+	case bulkUnassign:
+		res := tx.Where("task_id = ? AND user_id = ?", task.ID, body.UserID).Delete(&models.TaskAssignee{})
+		if res.Error != nil {
+			return ch, res.Error
+		}
+		if ch.added = res.RowsAffected > 0; ch.added {
+			if err := bumpTaskVersion(tx, task.ID); err != nil {
+				return ch, err
+			}
+			recordTaskActivity(tx, task, userID, models.ActivityUnassigned, "assignee", formatActivityValue(body.UserID), "")
+		}
+		return ch, nil
+
+	case bulkDelete:
+		res := q.Delete(&models.Task{})
+		if res.Error != nil {
+			return ch, res.Error
+		}
+		if versioned && res.RowsAffected == 0 {
+			return ch, errVersionConflict
+		}
+		recordTaskActivity(tx, task, userID, models.ActivityDeleted, "", task.Title, "")
+		return ch, nil
+	}
+
+	updated := map[string]interface{}{}
+	switch body.Operation {
+	case bulkSetStatus:
+		if body.Status == task.Status {
+			return ch, nil
+		}
+		rank, err := rankAtEndOfColumn(tx, task.ProjectID, body.Status)
+		if err != nil {
+			return ch, err
+		}
+		updated["status"] = body.Status
+		updated["board_rank"] = rank
+	case bulkSetPriority:
+		updated["priority"] = body.Priority
+	case bulkSetDueDate:
+		updated["due_date"] = body.DueDate
+		// nouvelle échéance : rappel et retard repartent de zéro
+		if (task.DueDate == nil) != (body.DueDate == nil) || (task.DueDate != nil && !task.DueDate.Equal(*body.DueDate)) {
+			updated["reminder_sent_at"] = nil
+			updated["overdue_at"] = nil
+			updated["escalated_at"] = nil
+		}
+	case bulkSetSprint:
+		if body.SprintID == 0 {
+			updated["sprint_id"] = nil
+		} else {
+			updated["sprint_id"] = body.SprintID
+		}
+	}
+
+	updated["version"] = gorm.Expr("version + 1")
+	res := q.Model(&models.Task{}).Updates(updated)
+	if res.Error != nil {
+		return ch, res.Error
+	}
+	if versioned && res.RowsAffected == 0 {
+		return ch, errVersionConflict
+	}
+	recordTaskChanges(tx, task, updated, userID)
+	ch.updated = updated
+	return ch, nil
+}
+
+// afterBulkChange sends the events and notifications of one applied change,
+// the same ones as the single-task endpoints
+func afterBulkChange(ch bulkChange, body bulkPayload, userID uint) {
+	before := ch.before
+	switch body.Operation {
+	case bulkAssign:
+		if ch.added {
+			watchTask(before.ID, body.UserID)
+			emitProjectEvent(before.ProjectID, userID, realtime.TaskAssigned, gin.H{"task_id": before.ID, "user_id": body.UserID})
+			notifyAssigned(before, body.UserID, userID)
+		}
+		return
+	case bulkUnassign:
+		if ch.added {
+			emitProjectEvent(before.ProjectID, userID, realtime.TaskUnassigned, gin.H{"task_id": before.ID, "user_id": body.UserID})
+		}
+		return
+	case bulkDelete:
+		emitProjectEvent(before.ProjectID, userID, realtime.TaskDeleted, gin.H{"task_id": before.ID})
+		return
+	}
+	if ch.updated == nil {
+		return
+	}
+
+	var task models.Task
+	if err := initializers.DB.Preload("Assignees.User").First(&task, before.ID).Error; err != nil {
+		return
+	}
+	emitProjectEvent(task.ProjectID, userID, realtime.TaskUpdated, gin.H{"task": task})
+	if task.Status != before.Status {
+		emitProjectEvent(task.ProjectID, userID, realtime.TaskStatusChanged, gin.H{"task": task, "from": before.Status, "to": task.Status})
+		notifyStatusChange(task, userID, before.Status, task.Status)
+		afterTaskDone(task)
+	}
+	changed := []string{}
+	for _, col := range []string{"priority", "due_date"} {
+		if _, ok := ch.updated[col]; ok && formatActivityValue(taskColumnValue(before, col)) != formatActivityValue(taskColumnValue(task, col)) {
+			changed = append(changed, col)
+		}
+	}
+	notifyTaskUpdated(task, userID, changed)
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/ical"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/notifications"
+)
+
+// calendarHistory : échéances passées gardées dans le flux
+const calendarHistory = 180 * 24 * time.Hour
+
+func newFeedToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// calendarFeedOf returns the feed of a user (projectID nil: assigned tasks),
+// creating it on first use; rotate replaces its token
+func calendarFeedOf(userID uint, projectID *uint, rotate bool) (models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	q := initializers.DB.Where("user_id = ?", userID)
+	if projectID == nil {
+		q = q.Where("project_id IS NULL")
+	} else {
+		q = q.Where("project_id = ?", *projectID)
+	}
+	err := q.First(&feed).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return feed, err
+	}
+	if err == nil && !rotate {
+		return feed, nil
+	}
+
+	token, terr := newFeedToken()
+	if terr != nil {
+		return feed, terr
+	}
+	if err != nil {
+		feed = models.CalendarFeed{UserID: userID, ProjectID: projectID, Token: token}
+		return feed, initializers.DB.Create(&feed).Error
+	}
+	feed.Token = token
+	return feed, initializers.DB.Model(&feed).Update("token", token).Error
+}
+
+func calendarFeedResponse(feed models.CalendarFeed) gin.H {
+	u := notifications.AppURL() + "/api/calendar/" + feed.Token + ".ics"
+	return gin.H{
+		"url":        u,
+		"webcal_url": "webcal://" + strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://"),
+		"project_id": feed.ProjectID,
+		"updated_at": feed.UpdatedAt,
+	}
+}
+
+func respondCalendarFeed(c *gin.Context, userID uint, projectID *uint, rotate bool) {
+	feed, err := calendarFeedOf(userID, projectID, rotate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load calendar feed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"feed": calendarFeedResponse(feed)})
+}
+
+// GetMyCalendarFeed returns the subscription URL of the feed of my assigned tasks
+func GetMyCalendarFeed(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	respondCalendarFeed(c, userID, nil, false)
+}
+
+// RotateMyCalendarFeed gives my feed a new token; the old URL stops working
+func RotateMyCalendarFeed(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	respondCalendarFeed(c, userID, nil, true)
+}
+
+// GetProjectCalendarFeed returns my subscription URL of a project's feed (members)
+func GetProjectCalendarFeed(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+	respondCalendarFeed(c, userID, &projectID, false)
+}
+
+// RotateProjectCalendarFeed gives my project feed a new token
+func RotateProjectCalendarFeed(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+	respondCalendarFeed(c, userID, &projectID, true)
+}
+
+// CalendarFeed serves a feed by its token, without authentication.
+// Query: component=vevent (default) or vtodo
+func CalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	component := strings.ToUpper(c.DefaultQuery("component", "vevent"))
+	if component != "VEVENT" && component != "VTODO" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "component must be vevent or vtodo"})
+		return
+	}
+
+	var feed models.CalendarFeed
+	if token == "" || initializers.DB.Where("token = ?", token).First(&feed).Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendar not found"})
+		return
+	}
+
+	since := time.Now().Add(-calendarHistory)
+	q := initializers.DB.
+		Where("tasks.due_date IS NOT NULL AND tasks.due_date >= ?", since).
+		Order("tasks.due_date").Order("tasks.id")
+	name := "My tasks"
+	if feed.ProjectID != nil {
+		// un ancien membre ne voit plus le projet
+		isMember, err := IsProjectMember(*feed.ProjectID, feed.UserID)
+		if err != nil || !isMember {
+			c.JSON(http.StatusNotFound, gin.H{"error": "calendar not found"})
+			return
+		}
+		var project models.Project
+		if err := initializers.DB.Select("id", "name").First(&project, *feed.ProjectID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "calendar not found"})
+			return
+		}
+		name = project.Name
+		q = q.Where("tasks.project_id = ?", *feed.ProjectID)
+	} else {
+		q = q.Where("tasks.id IN (SELECT task_id FROM task_assignees WHERE user_id = ? AND deleted_at IS NULL)", feed.UserID).
+			Where("tasks.project_id IN (SELECT project_id FROM project_members WHERE user_id = ? AND deleted_at IS NULL)", feed.UserID)
+	}
+	var tasks []models.Task
+	if err := q.Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+
+	projects := map[uint]string{}
+	ids := []uint{}
+	for _, t := range tasks {
+		if _, ok := projects[t.ProjectID]; !ok {
+			projects[t.ProjectID] = ""
+			ids = append(ids, t.ProjectID)
+		}
+	}
+	if len(ids) > 0 {
+		var list []models.Project
+		initializers.DB.Select("id", "name").Where("id IN ?", ids).Find(&list)
+		for _, p := range list {
+			projects[p.ID] = p.Name
+		}
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+	c.Status(http.StatusOK)
+	if err := writeTaskCalendar(c.Writer, name, component, tasks, projects); err != nil {
+		log.Printf("calendar feed %d: %v", feed.ID, err)
+	}
+}
+
+// writeTaskCalendar writes one VEVENT or VTODO per task, all times in UTC
+func writeTaskCalendar(w http.ResponseWriter, name, component string, tasks []models.Task, projects map[uint]string) error {
+	host := "task-manager"
+	if u, err := url.Parse(notifications.AppURL()); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	now := time.Now()
+
+	cal := ical.NewWriter(w)
+	cal.Begin("VCALENDAR")
+	cal.Prop("VERSION", "2.0")
+	cal.Prop("PRODID", "-//Task Manager//Tasks//EN")
+	cal.Prop("CALSCALE", "GREGORIAN")
+	cal.Prop("METHOD", "PUBLISH")
+	cal.Text("X-WR-CALNAME", name)
+	cal.Prop("X-PUBLISHED-TTL", "PT1H")
+	cal.Prop("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+
+	for _, t := range tasks {
+		link := notifications.TaskURL(t.ProjectID, t.ID)
+		desc := fmt.Sprintf("Status: %s\nPriority: %s\nProject: %s\n%s", t.Status, t.Priority, projects[t.ProjectID], link)
+		if t.Description != "" {
+			desc += "\n\n" + t.Description
+		}
+
+		cal.Begin(component)
+		cal.Prop("UID", fmt.Sprintf("task-%d@%s", t.ID, host))
+		cal.Time("DTSTAMP", now)
+		cal.Time("LAST-MODIFIED", t.UpdatedAt)
+		cal.Text("DESCRIPTION", desc)
+		cal.Prop("URL", link)
+		cal.Text("CATEGORIES", projects[t.ProjectID])
+		if component == "VTODO" {
+			cal.Text("SUMMARY", t.Title)
+			cal.Time("DUE", *t.DueDate)
+			cal.Prop("STATUS", map[string]string{
+				models.TaskStatusTodo:  "NEEDS-ACTION",
+				models.TaskStatusDoing: "IN-PROCESS",
+				models.TaskStatusDone:  "COMPLETED",
+			}[t.Status])
+			cal.Prop("PRIORITY", map[string]string{
+				models.TaskPriorityHigh:   "1",
+				models.TaskPriorityMedium: "5",
+				models.TaskPriorityLow:    "9",
+			}[t.Priority])
+			if t.Status == models.TaskStatusDone {
+				cal.Time("COMPLETED", t.UpdatedAt)
+			}
+		} else {
+			summary := t.Title
+			if t.Status == models.TaskStatusDone {
+				summary = "✓ " + summary
+			}
+			cal.Text("SUMMARY", summary)
+			// sans DTEND, l'événement dure zéro minute à l'échéance
+			cal.Time("DTSTART", *t.DueDate)
+			cal.Prop("TRANSP", "TRANSPARENT")
+		}
+		cal.End(component)
+	}
+	cal.End("VCALENDAR")
+	return cal.Flush()
+}
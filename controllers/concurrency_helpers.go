@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// Versions: a task's version goes up on every write a client could
+// overwrite with a stale copy — its fields, its assignees, its sprint, its
+// remaining estimate — so If-Match fails (412) after any of them. Internal
+// bookkeeping (board rank rebalancing, reminder timestamps, the link to the
+// next occurrence) does not bump it. A project's version covers its
+// settings: name, description, estimate scale, WIP mode.
+
+// setETag exposes the version of a task or project as a strong ETag ("3")
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", `"`+strconv.FormatUint(uint64(version), 10)+`"`)
+}
+
+// parseETag accepts `"3"`, `W/"3"` and `3`
+func parseETag(s string) (uint, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "W/")
+	s = strings.Trim(s, `"`)
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(v), true
+}
+
+// requireVersion returns the version the client based its change on, taken
+// from If-Match, else from bodyVersion (a "version" field of the payload or
+// query). It writes 428/400 and returns false when none is usable.
+func requireVersion(c *gin.Context, bodyVersion *uint) (uint, bool) {
+	if h := c.GetHeader("If-Match"); h != "" {
+		v, ok := parseETag(h)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid If-Match header"})
+			return 0, false
+		}
+		return v, true
+	}
+	if bodyVersion != nil {
+		return *bodyVersion, true
+	}
+	c.JSON(http.StatusPreconditionRequired, gin.H{"error": "missing If-Match header or version"})
+	return 0, false
+}
+
+// queryVersion reads ?version= for requests without a body (DELETE)
+func queryVersion(c *gin.Context) *uint {
+	v, ok := parseETag(c.Query("version"))
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// bumpTaskVersion marks a change made outside the task row (assignees)
+func bumpTaskVersion(db *gorm.DB, taskID uint) error {
+	return db.Model(&models.Task{}).Where("id = ?", taskID).
+		Updates(map[string]interface{}{"version": gorm.Expr("version + 1")}).Error
+}
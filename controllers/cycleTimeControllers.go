@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// durationStats : percentiles en heures d'une série de durées
+type durationStats struct {
+	Count   int      `json:"count"`
+	P50     *float64 `json:"p50"`
+	P85     *float64 `json:"p85"`
+	P95     *float64 `json:"p95"`
+	Average *float64 `json:"average"`
+}
+
+// newDurationStats computes nearest-rank percentiles of hours
+func newDurationStats(hours []float64) durationStats {
+	s := durationStats{Count: len(hours)}
+	if len(hours) == 0 {
+		return s
+	}
+	sorted := append([]float64(nil), hours...)
+	sort.Float64s(sorted)
+	rank := func(p float64) *float64 {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		v := round1(sorted[i])
+		return &v
+	}
+	s.P50, s.P85, s.P95 = rank(50), rank(85), rank(95)
+	sum := 0.0
+	for _, h := range sorted {
+		sum += h
+	}
+	avg := round1(sum / float64(len(sorted)))
+	s.Average = &avg
+	return s
+}
+
+// flowTime is the lead and cycle time of one finished task
+type flowTime struct {
+	TaskID    uint       `json:"task_id"`
+	Title     string     `json:"title"`
+	Priority  string     `json:"priority"`
+	CreatedAt time.Time  `json:"created_at"`
+	StartedAt *time.Time `json:"started_at"` // premier passage en DOING
+	DoneAt    time.Time  `json:"done_at"`
+	LeadTime  float64    `json:"lead_time_hours"`
+	CycleTime *float64   `json:"cycle_time_hours"` // null si jamais passée par DOING
+}
+
+// flowGroup : lead/cycle time d'un groupe de tâches (priorité, assigné)
+type flowGroup struct {
+	Key       string        `json:"key"`
+	UserID    *uint         `json:"user_id,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	LeadTime  durationStats `json:"lead_time"`
+	CycleTime durationStats `json:"cycle_time"`
+
+	lead, cycle []float64
+}
+
+func (g *flowGroup) add(t flowTime) {
+	g.lead = append(g.lead, t.LeadTime)
+	if t.CycleTime != nil {
+		g.cycle = append(g.cycle, *t.CycleTime)
+	}
+}
+
+func (g *flowGroup) finish() {
+	g.LeadTime = newDurationStats(g.lead)
+	g.CycleTime = newDurationStats(g.cycle)
+}
+
+// cycleTimeWindow : from/to (YYYY-MM-DD) or the `days` days (default 90)
+// ending at `to` (default today), on the done date. Days are local days,
+// as in the other reports (truncateDay).
+func cycleTimeWindow(c *gin.Context) (time.Time, time.Time, bool) {
+	days, ok := queryInt(c, "days", 90, 730)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to := truncateDay(time.Now())
+	if v := c.Query("to"); v != "" {
+		var err error
+		if to, err = time.ParseInLocation(filterDateLayout, v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	from := to.AddDate(0, 0, -days+1)
+	if v := c.Query("from"); v != "" {
+		var err error
+		if from, err = time.ParseInLocation(filterDateLayout, v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to is before from"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to.AddDate(0, 0, 1), true
+}
+
+// GetProjectCycleTime reports lead time (creation → DONE) and cycle time
+// (first DOING → DONE) of the tasks finished in the window, with percentiles,
+// a scatter dataset and breakdowns by priority and assignee (tasks have no
+// labels in this model, so there is no breakdown by label).
+func GetProjectCycleTime(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	from, to, ok := cycleTimeWindow(c)
+	if !ok {
+		return
+	}
+
+	done, err := doneTasks(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+	ids := make([]uint, 0, len(done))
+	for _, t := range done {
+		if !t.DoneAt.Before(from) && t.DoneAt.Before(to) {
+			ids = append(ids, t.ID)
+		}
+	}
+
+	// premier passage en DOING de chaque tâche
+	started := map[uint]time.Time{}
+	assignees := map[uint][]models.TaskAssignee{}
+	if len(ids) > 0 {
+		var rows []struct {
+			TaskID    uint
+			StartedAt time.Time
+		}
+		if err := initializers.DB.Model(&models.TaskActivity{}).
+			Select("task_id, MIN(created_at) AS started_at").
+			Where("task_id IN ? AND type = ? AND field = ? AND new_value = ?",
+				ids, models.ActivityUpdated, "status", models.TaskStatusDoing).
+			Group("task_id").
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load history"})
+			return
+		}
+		for _, r := range rows {
+			started[r.TaskID] = r.StartedAt
+		}
+
+		var list []models.TaskAssignee
+		if err := initializers.DB.Preload("User").Where("task_id IN ?", ids).Find(&list).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load assignees"})
+			return
+		}
+		for _, a := range list {
+			assignees[a.TaskID] = append(assignees[a.TaskID], a)
+		}
+	}
+
+	all := flowGroup{}
+	byPriority := map[string]*flowGroup{}
+	byAssignee := map[uint]*flowGroup{}
+	unassigned := &flowGroup{Key: "unassigned"}
+	scatter := make([]flowTime, 0, len(ids))
+	for _, t := range done {
+		if t.DoneAt.Before(from) || !t.DoneAt.Before(to) {
+			continue
+		}
+		ft := flowTime{
+			TaskID:    t.ID,
+			Title:     t.Title,
+			Priority:  t.Priority,
+			CreatedAt: t.CreatedAt,
+			DoneAt:    t.DoneAt,
+			LeadTime:  round1(math.Max(t.DoneAt.Sub(t.CreatedAt).Hours(), 0)),
+		}
+		if at, ok := started[t.ID]; ok && !at.After(t.DoneAt) {
+			at := at
+			cycle := round1(t.DoneAt.Sub(at).Hours())
+			ft.StartedAt, ft.CycleTime = &at, &cycle
+		}
+		scatter = append(scatter, ft)
+
+		all.add(ft)
+		g := byPriority[t.Priority]
+		if g == nil {
+			g = &flowGroup{Key: t.Priority}
+			byPriority[t.Priority] = g
+		}
+		g.add(ft)
+		if len(assignees[t.ID]) == 0 {
+			unassigned.add(ft)
+		}
+		for _, a := range assignees[t.ID] {
+			g := byAssignee[a.UserID]
+			if g == nil {
+				uid := a.UserID
+				g = &flowGroup{Key: "user", UserID: &uid, Name: a.User.Name}
+				byAssignee[a.UserID] = g
+			}
+			g.add(ft)
+		}
+	}
+	sort.Slice(scatter, func(i, j int) bool { return scatter[i].DoneAt.Before(scatter[j].DoneAt) })
+
+	priorities := make([]flowGroup, 0, len(byPriority))
+	for _, p := range []string{models.TaskPriorityHigh, models.TaskPriorityMedium, models.TaskPriorityLow} {
+		if g := byPriority[p]; g != nil {
+			g.finish()
+			priorities = append(priorities, *g)
+			delete(byPriority, p)
+		}
+	}
+	for _, g := range byPriority {
+		g.finish()
+		priorities = append(priorities, *g)
+	}
+
+	people := make([]flowGroup, 0, len(byAssignee)+1)
+	for _, g := range byAssignee {
+		g.finish()
+		people = append(people, *g)
+	}
+	sort.Slice(people, func(i, j int) bool { return people[i].Name < people[j].Name })
+	if len(unassigned.lead) > 0 {
+		unassigned.finish()
+		people = append(people, *unassigned)
+	}
+
+	all.finish()
+	c.JSON(http.StatusOK, gin.H{
+		"from":        from.Format(filterDateLayout),
+		"to":          to.AddDate(0, 0, -1).Format(filterDateLayout),
+		"lead_time":   all.LeadTime,
+		"cycle_time":  all.CycleTime,
+		"by_priority": priorities,
+		"by_assignee": people,
+		"scatter":     scatter,
+	})
+}
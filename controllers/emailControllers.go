@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/notifications"
+)
+
+type emailPrefsPayload struct {
+	Mode       string `json:"mode"` // OFF, IMMEDIATE or DIGEST
+	DigestHour *int   `json:"digest_hour"`
+}
+
+// GetEmailPreferences returns how the caller receives emails
+func GetEmailPreferences(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	pref, err := notifications.EmailPreferenceOf(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
+
+// SetEmailPreferences changes the email mode and digest hour of the caller
+func SetEmailPreferences(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var body emailPrefsPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch body.Mode {
+	case models.EmailOff, models.EmailImmediate, models.EmailDigest:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be OFF, IMMEDIATE or DIGEST"})
+		return
+	}
+	if body.DigestHour != nil && (*body.DigestHour < 0 || *body.DigestHour > 23) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "digest_hour must be between 0 and 23"})
+		return
+	}
+
+	pref, err := notifications.SetEmailMode(userID, body.Mode, body.DigestHour)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html><body style="font-family: Arial, sans-serif;">
+{{if .Done}}<p>You will no longer receive emails from Task Manager.</p>
+{{else}}<form method="POST" action="">
+  <p>Stop receiving all emails from Task Manager?</p>
+  <button type="submit">Unsubscribe</button>
+</form>{{end}}
+</body></html>`))
+
+// unsubscribeUser reads and checks the signed uid/token of the link
+func unsubscribeUser(c *gin.Context) (uint, bool) {
+	uid64, err := strconv.ParseUint(c.Query("uid"), 10, 64)
+	if err != nil || !notifications.VerifyUnsubscribeToken(uint(uid64), c.Query("token")) {
+		c.String(http.StatusBadRequest, "invalid unsubscribe link")
+		return 0, false
+	}
+	return uint(uid64), true
+}
+
+// UnsubscribeEmailPage shows a confirmation button. GET never changes
+// anything, so link scanners of mail providers cannot unsubscribe users.
+func UnsubscribeEmailPage(c *gin.Context) {
+	if _, ok := unsubscribeUser(c); !ok {
+		return
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	unsubscribePage.Execute(c.Writer, gin.H{"Done": false})
+}
+
+// UnsubscribeEmail turns emails off for the signed user. It is also the
+// target of the one-click List-Unsubscribe-Post header (RFC 8058).
+func UnsubscribeEmail(c *gin.Context) {
+	userID, ok := unsubscribeUser(c)
+	if !ok {
+		return
+	}
+	if _, err := notifications.SetEmailMode(userID, models.EmailOff, nil); err != nil {
+		c.String(http.StatusInternalServerError, "could not unsubscribe")
+		return
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	unsubscribePage.Execute(c.Writer, gin.H{"Done": true})
+}
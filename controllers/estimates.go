@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// points of each allowed estimate, per scale; HOURS accepts any number >= 0
+var (
+	fibonacciPoints = map[string]float64{
+		"0": 0, "0.5": 0.5, "1": 1, "2": 2, "3": 3, "5": 5, "8": 8, "13": 13, "20": 20, "40": 40, "100": 100,
+	}
+	tshirtPoints = map[string]float64{
+		"XS": 1, "S": 2, "M": 3, "L": 5, "XL": 8, "XXL": 13,
+	}
+	estimateScales = map[string]bool{models.EstimateFibonacci: true, models.EstimateTShirt: true, models.EstimateHours: true}
+)
+
+// parseEstimate validates an estimate in the scale of a project and returns
+// its canonical label and value in points. An empty value clears the estimate.
+func parseEstimate(scale, value string) (string, *float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil, nil
+	}
+	switch scale {
+	case models.EstimateTShirt:
+		label := strings.ToUpper(value)
+		p, ok := tshirtPoints[label]
+		if !ok {
+			return "", nil, errors.New("estimate must be one of XS, S, M, L, XL, XXL")
+		}
+		return label, &p, nil
+	case models.EstimateHours:
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil || p < 0 || p > 10000 {
+			return "", nil, errors.New("estimate must be a number of hours")
+		}
+		return strconv.FormatFloat(p, 'f', -1, 64), &p, nil
+	default:
+		p, ok := fibonacciPoints[value]
+		if !ok {
+			return "", nil, errors.New("estimate must be one of 0, 0.5, 1, 2, 3, 5, 8, 13, 20, 40, 100")
+		}
+		return value, &p, nil
+	}
+}
+
+// projectEstimateScale returns the estimate scale of a project
+func projectEstimateScale(projectID uint) (string, error) {
+	var scales []string
+	if err := initializers.DB.Model(&models.Project{}).Where("id = ?", projectID).Pluck("estimate_scale", &scales).Error; err != nil {
+		return "", err
+	}
+	if len(scales) == 0 || scales[0] == "" {
+		return models.EstimateFibonacci, nil
+	}
+	return scales[0], nil
+}
+
+// remapEstimates moves the estimates of a project's tasks to a new scale:
+// an estimate that exists in the new scale (e.g. "3" from FIBONACCI to HOURS)
+// is kept with its new points, the others are cleared. Returns the number of
+// cleared estimates.
+func remapEstimates(tx *gorm.DB, projectID uint, scale string) (int, error) {
+	var tasks []models.Task
+	if err := tx.Select("id", "estimate", "story_points").Where("project_id = ? AND estimate <> ''", projectID).Find(&tasks).Error; err != nil {
+		return 0, err
+	}
+	cleared := 0
+	for _, t := range tasks {
+		label, points, err := parseEstimate(scale, t.Estimate)
+		if err != nil {
+			label, points = "", nil
+			cleared++
+		}
+		if err := tx.Model(&models.Task{}).Where("id = ?", t.ID).Updates(map[string]interface{}{
+			"estimate":     label,
+			"story_points": points,
+			"version":      gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return cleared, err
+		}
+	}
+	return cleared, nil
+}
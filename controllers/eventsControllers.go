@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+)
+
+// heartbeatInterval: keep-alive comment + membership re-check on open streams
+const heartbeatInterval = 20 * time.Second
+
+// emitProjectEvent is how controllers report a change of a project
+func emitProjectEvent(projectID uint, actorID uint, typ string, data interface{}) {
+	realtime.DefaultHub.Publish(projectID, typ, actorID, data)
+}
+
+// writeSSE writes one Server-Sent Event and flushes it
+func writeSSE(c *gin.Context, ev realtime.Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, payload); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// ProjectEvents streams the events of a project as Server-Sent Events.
+// Resumes after the Last-Event-ID header (or ?last_event_id=).
+func ProjectEvents(c *gin.Context) {
+	pidStr := c.Param("projectId")
+	pid64, err := strconv.ParseUint(pidStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	projectID := uint(pid64)
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	isMember, err := IsProjectMember(projectID, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+		return
+	}
+
+	var lastEventID uint64
+	lastStr := c.GetHeader("Last-Event-ID")
+	if lastStr == "" {
+		lastStr = c.Query("last_event_id")
+	}
+	if lastStr != "" {
+		if lastEventID, err = strconv.ParseUint(lastStr, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last event id"})
+			return
+		}
+	}
+
+	events, missed, resync, cancel := realtime.DefaultHub.Subscribe(projectID, lastEventID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// retry hint for EventSource, then what was missed while disconnected
+	fmt.Fprintf(c.Writer, "retry: 3000\n\n")
+	if resync {
+		fmt.Fprintf(c.Writer, "event: resync\ndata: {}\n\n")
+	}
+	for _, ev := range missed {
+		if err := writeSSE(c, ev); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case ev, open := <-events:
+			if !open {
+				// dropped by the hub (too slow): the client reconnects and resumes
+				return
+			}
+			if err := writeSSE(c, ev); err != nil {
+				return
+			}
+			if ev.Type == realtime.ProjectDeleted {
+				fmt.Fprintf(c.Writer, "event: revoked\ndata: {}\n\n")
+				c.Writer.Flush()
+				return
+			}
+			if ev.Type == realtime.MemberRemoved {
+				if isMember, err := IsProjectMember(projectID, userID); err != nil || !isMember {
+					fmt.Fprintf(c.Writer, "event: revoked\ndata: {}\n\n")
+					c.Writer.Flush()
+					return
+				}
+			}
+
+		case <-heartbeat.C:
+			if isMember, err := IsProjectMember(projectID, userID); err != nil || !isMember {
+				fmt.Fprintf(c.Writer, "event: revoked\ndata: {}\n\n")
+				c.Writer.Flush()
+				return
+			}
+			if _, err := fmt.Fprintf(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/export"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// exportBatch : tâches lues avant de charger leurs assignés et de les écrire
+const exportBatch = 500
+
+// exportColumn is one exportable column of a task
+type exportColumn struct {
+	name  string
+	value func(t models.Task, assignees []models.TaskAssignee) interface{}
+}
+
+func assigneeField(list []models.TaskAssignee, field func(models.User) string) string {
+	parts := make([]string, 0, len(list))
+	for _, a := range list {
+		parts = append(parts, field(a.User))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// exportColumns in their default order
+var exportColumns = []exportColumn{
+	{"id", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.ID }},
+	{"title", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.Title }},
+	{"description", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.Description }},
+	{"status", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.Status }},
+	{"priority", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.Priority }},
+	{"due_date", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.DueDate }},
+	{"assignees", func(_ models.Task, a []models.TaskAssignee) interface{} {
+		return assigneeField(a, func(u models.User) string { return u.Name })
+	}},
+	{"assignee_emails", func(_ models.Task, a []models.TaskAssignee) interface{} {
+		return assigneeField(a, func(u models.User) string { return u.Email })
+	}},
+	{"sprint_id", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.SprintID }},
+	{"estimate", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.Estimate }},
+	{"story_points", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.StoryPoints }},
+	{"original_estimate_minutes", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.OriginalEstimate }},
+	{"remaining_estimate_minutes", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.RemainingEstimate }},
+	{"recurrence", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.Recurrence }},
+	{"creator_id", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.CreatorID }},
+	{"created_at", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.CreatedAt }},
+	{"updated_at", func(t models.Task, _ []models.TaskAssignee) interface{} { return t.UpdatedAt }},
+}
+
+// parseExportColumns reads ?columns=title,status,... (all columns by default)
+func parseExportColumns(raw string) ([]exportColumn, error) {
+	if strings.TrimSpace(raw) == "" {
+		return exportColumns, nil
+	}
+	byName := make(map[string]exportColumn, len(exportColumns))
+	for _, col := range exportColumns {
+		byName[col.name] = col
+	}
+	var out []exportColumn
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		col, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		out = append(out, col)
+	}
+	return out, nil
+}
+
+// ExportProjectTasks streams the tasks of a project as CSV, JSON or XLSX.
+// Query: format, columns, filter, sort, desc (same as the task list)
+func ExportProjectTasks(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+
+	format := c.DefaultQuery("format", export.CSV)
+	contentType, ok := export.ContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, json or xlsx"})
+		return
+	}
+	columns, err := parseExportColumns(c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := parseTaskFilter(c.Query("filter"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sortBy := c.Query("sort")
+	if _, ok := taskSortExpressions[sortBy]; sortBy != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort"})
+		return
+	}
+
+	q := initializers.DB.Model(&models.Task{}).Where("tasks.project_id = ?", projectID)
+	q = applyTaskFilter(q, filter, userID)
+	if sortBy == "" {
+		q = q.Order(taskSortExpressions["status"]).Order("tasks.board_rank")
+	}
+	q = applyTaskSort(q, sortBy, c.Query("desc") == "true")
+
+	// curseur SQL : les tâches sont lues au fil de l'écriture
+	rows, err := q.Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("project-%d-tasks-%s.%s", projectID, time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w, _ := export.New(format, c.Writer)
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+	if err := w.WriteHeader(names); err != nil {
+		log.Printf("export of project %d aborted: %v", projectID, err)
+		return
+	}
+
+	batch := make([]models.Task, 0, exportBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(batch))
+		for i, t := range batch {
+			ids[i] = t.ID
+		}
+		var list []models.TaskAssignee
+		if err := initializers.DB.Preload("User").Where("task_id IN ?", ids).Order("id").Find(&list).Error; err != nil {
+			return err
+		}
+		assignees := map[uint][]models.TaskAssignee{}
+		for _, a := range list {
+			assignees[a.TaskID] = append(assignees[a.TaskID], a)
+		}
+		values := make([]interface{}, len(columns))
+		for _, t := range batch {
+			for i, col := range columns {
+				values[i] = col.value(t, assignees[t.ID])
+			}
+			if err := w.WriteRow(values); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		var t models.Task
+		if err := initializers.DB.ScanRows(rows, &t); err != nil {
+			log.Printf("export of project %d aborted: %v", projectID, err)
+			return
+		}
+		batch = append(batch, t)
+		if len(batch) == exportBatch {
+			if err := flush(); err != nil {
+				log.Printf("export of project %d aborted: %v", projectID, err)
+				return
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("export of project %d aborted: %v", projectID, err)
+		return
+	}
+	if err := flush(); err != nil {
+		log.Printf("export of project %d aborted: %v", projectID, err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("export of project %d aborted: %v", projectID, err)
+	}
+}
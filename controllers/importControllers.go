@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/importer"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+)
+
+const (
+	maxImportBytes      = 10 << 20
+	importPreviewRows   = 20
+	importProgressEvery = 100
+)
+
+// importReport is the validation report returned by dry runs and imports
+type importReport struct {
+	Format   string           `json:"format"`
+	Rows     int              `json:"rows"`    // tâches lues
+	Valid    int              `json:"valid"`   // tâches importables
+	Skipped  int              `json:"skipped"` // cartes archivées (Trello)
+	Errors   []importer.Issue `json:"errors"`
+	Warnings []importer.Issue `json:"warnings"`
+	Preview  []importer.Row   `json:"preview"`
+	// limites WIP dépassées une fois l'import fait (bloquant en mode block)
+	WipViolations []wipViolation `json:"wip_violations"`
+}
+
+// importTask is a validated row ready to insert
+type importTask struct {
+	task      models.Task
+	assignees []uint
+}
+
+// readImportFile returns the uploaded file (multipart field "file") or the raw body
+func readImportFile(c *gin.Context) (io.ReadCloser, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		f, err := fh.Open()
+		return f, fh.Filename, err
+	}
+	return c.Request.Body, "", nil
+}
+
+// memberDirectory resolves the emails or names of a file to project members
+type memberDirectory struct {
+	byEmail map[string]uint
+	byName  map[string]uint // 0 = plusieurs membres portent ce nom
+}
+
+func loadMemberDirectory(projectID uint) (memberDirectory, error) {
+	d := memberDirectory{byEmail: map[string]uint{}, byName: map[string]uint{}}
+	var members []models.ProjectMember
+	if err := initializers.DB.Preload("User").Where("project_id = ?", projectID).Find(&members).Error; err != nil {
+		return d, err
+	}
+	for _, m := range members {
+		d.byEmail[strings.ToLower(m.User.Email)] = m.UserID
+		name := strings.ToLower(strings.TrimSpace(m.User.Name))
+		if _, dup := d.byName[name]; dup {
+			d.byName[name] = 0
+		} else {
+			d.byName[name] = m.UserID
+		}
+	}
+	return d, nil
+}
+
+func (d memberDirectory) lookup(ref string) uint {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if strings.Contains(ref, "@") {
+		return d.byEmail[ref]
+	}
+	return d.byName[ref]
+}
+
+// validateImport checks the parsed rows against the project: assignees must
+// be members, estimates must fit the project scale
+func validateImport(projectID, userID uint, format string, res *importer.Result) ([]importTask, importReport, error) {
+	report := importReport{
+		Format:   format,
+		Rows:     len(res.Rows) + countLines(res.Errors),
+		Skipped:  res.Skipped,
+		Errors:   res.Errors,
+		Warnings: res.Warnings,
+	}
+	dir, err := loadMemberDirectory(projectID)
+	if err != nil {
+		return nil, report, err
+	}
+	scale, err := projectEstimateScale(projectID)
+	if err != nil {
+		return nil, report, err
+	}
+
+	tasks := make([]importTask, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		t := models.Task{
+			Title:       row.Title,
+			Description: row.Description,
+			Status:      row.Status,
+			Priority:    row.Priority,
+			DueDate:     row.DueDate,
+			ProjectID:   projectID,
+			CreatorID:   userID,
+		}
+		if row.Estimate != "" {
+			label, points, err := parseEstimate(scale, row.Estimate)
+			if err != nil {
+				report.Warnings = append(report.Warnings, importer.Issue{Line: row.Line, Field: "estimate", Message: err.Error() + ", estimate dropped"})
+			} else {
+				t.Estimate, t.StoryPoints = label, points
+			}
+		}
+		if row.OriginalEstimate != nil {
+			remaining := *row.OriginalEstimate
+			t.OriginalEstimate, t.RemainingEstimate = row.OriginalEstimate, &remaining
+		}
+
+		var assignees []uint
+		seen := map[uint]bool{}
+		for _, ref := range row.Assignees {
+			id := dir.lookup(ref)
+			if id == 0 {
+				report.Warnings = append(report.Warnings, importer.Issue{Line: row.Line, Field: "assignees", Message: "no project member matches " + strconv.Quote(ref) + ", left unassigned"})
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				assignees = append(assignees, id)
+			}
+		}
+		tasks = append(tasks, importTask{task: t, assignees: assignees})
+		if len(report.Preview) < importPreviewRows {
+			report.Preview = append(report.Preview, row)
+		}
+	}
+	report.Valid = len(tasks)
+	if report.Errors == nil {
+		report.Errors = []importer.Issue{}
+	}
+	if report.Warnings == nil {
+		report.Warnings = []importer.Issue{}
+	}
+	return tasks, report, nil
+}
+
+// checkImportWipLimits checks the WIP limits of every column the import adds to
+func checkImportWipLimits(projectID uint, tasks []importTask) (string, []wipViolation, error) {
+	added := map[string]int{}
+	addedBy := map[string]map[uint]int{}
+	for _, it := range tasks {
+		st := it.task.Status
+		added[st]++
+		if addedBy[st] == nil {
+			addedBy[st] = map[uint]int{}
+		}
+		for _, uid := range it.assignees {
+			addedBy[st][uid]++
+		}
+	}
+	mode := ""
+	violations := []wipViolation{}
+	for status, n := range added {
+		m, v, err := checkAddedWipLimits(projectID, status, nil, n, addedBy[status])
+		if err != nil {
+			return m, nil, err
+		}
+		mode = m
+		violations = append(violations, v...)
+	}
+	return mode, violations, nil
+}
+
+// countLines counts the distinct lines with an error (rows that were not kept)
+func countLines(issues []importer.Issue) int {
+	lines := map[int]bool{}
+	for _, is := range issues {
+		lines[is.Line] = true
+	}
+	return len(lines)
+}
+
+// ImportProjectTasks imports tasks from a file (members).
+// Query: format=csv|json|trello|jira, dry_run=true, mapping={"title":"Summary",...}
+// A dry run only returns the validation report; otherwise the import runs in
+// the background and its progress is read from GET .../imports/:importId.
+func ImportProjectTasks(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+
+	format := c.Query("format")
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format is required (csv, json, trello or jira)"})
+		return
+	}
+	var mapping map[string]string
+	if raw := c.Query("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object of field → column"})
+			return
+		}
+	}
+
+	file, filename, err := readImportFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	res, err := importer.Parse(format, file, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tasks, report, err := validateImport(projectID, userID, format, res)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	mode, violations, err := checkImportWipLimits(projectID, tasks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	report.WipViolations = violations
+
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "report": report})
+		return
+	}
+	// tout ou rien : on n'importe pas un fichier avec des lignes invalides
+	if len(report.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "file has errors, nothing imported", "report": report})
+		return
+	}
+	if len(tasks) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to import", "report": report})
+		return
+	}
+	if len(violations) > 0 && mode == models.WipModeBlock {
+		c.JSON(http.StatusConflict, gin.H{"error": "WIP limit exceeded, nothing imported", "violations": violations, "report": report})
+		return
+	}
+
+	job := models.ImportJob{
+		ProjectID: projectID,
+		UserID:    userID,
+		Format:    format,
+		Filename:  filename,
+		Status:    models.ImportRunning,
+		Total:     len(tasks),
+	}
+	if err := initializers.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start import"})
+		return
+	}
+	go runImport(job, tasks)
+
+	c.JSON(http.StatusAccepted, gin.H{"import": job, "report": report})
+}
+
+// runImport creates the tasks in one transaction and records the progress
+func runImport(job models.ImportJob, tasks []importTask) {
+	columns := map[string]bool{}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range tasks {
+			t := &tasks[i].task
+			// rang vide : les tâches importées passent en fin de colonne au rééquilibrage
+			if err := tx.Create(t).Error; err != nil {
+				return err
+			}
+			columns[t.Status] = true
+
+			watchers := []models.TaskWatcher{{TaskID: t.ID, UserID: job.UserID}}
+			for _, uid := range tasks[i].assignees {
+				if err := tx.Create(&models.TaskAssignee{TaskID: t.ID, UserID: uid}).Error; err != nil {
+					return err
+				}
+				watchers = append(watchers, models.TaskWatcher{TaskID: t.ID, UserID: uid})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&watchers).Error; err != nil {
+				return err
+			}
+			recordTaskActivity(tx, *t, job.UserID, models.ActivityCreated, "", "", t.Title)
+
+			if (i+1)%importProgressEvery == 0 {
+				initializers.DB.Model(&models.ImportJob{}).Where("id = ?", job.ID).Update("processed", i+1)
+			}
+		}
+		for status := range columns {
+			if err := rebalanceColumn(tx, job.ProjectID, status); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	now := time.Now()
+	updated := map[string]interface{}{"finished_at": now}
+	if err != nil {
+		log.Printf("import %d into project %d failed: %v", job.ID, job.ProjectID, err)
+		updated["status"] = models.ImportFailed
+		updated["error"] = err.Error()
+	} else {
+		updated["status"] = models.ImportDone
+		updated["processed"] = len(tasks)
+		updated["imported"] = len(tasks)
+	}
+	initializers.DB.Model(&models.ImportJob{}).Where("id = ?", job.ID).Updates(updated)
+
+	if err == nil {
+		emitProjectEvent(job.ProjectID, job.UserID, realtime.TasksImported, gin.H{"import_id": job.ID, "count": len(tasks)})
+	}
+}
+
+// GetImportJob returns the progress of an import (members)
+func GetImportJob(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	id64, err := strconv.ParseUint(c.Param("importId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import id"})
+		return
+	}
+	var job models.ImportJob
+	if err := initializers.DB.Where("id = ? AND project_id = ?", uint(id64), projectID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"import": job})
+}
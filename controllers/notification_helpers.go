@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/notifications"
+)
+
+// mentionPattern matches @alice, @alice.smith or @alice@example.com
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9._%+-]+(?:@[A-Za-z0-9.-]+\.[A-Za-z]{2,})?)`)
+
+// mentionTokens returns the lower-cased mention tokens found in text
+func mentionTokens(text string) map[string]bool {
+	tokens := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		tokens[strings.ToLower(strings.TrimRight(m[1], "."))] = true
+	}
+	return tokens
+}
+
+// mentionedMembers resolves the mentions of text that are new compared to
+// oldText into project members. A token matches the full email, the part
+// before the @, or the name without spaces (case-insensitive).
+func mentionedMembers(projectID uint, oldText, text string) ([]uint, error) {
+	tokens := mentionTokens(text)
+	for t := range mentionTokens(oldText) {
+		delete(tokens, t)
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	var members []models.ProjectMember
+	if err := initializers.DB.Preload("User").Where("project_id = ?", projectID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	ids := []uint{}
+	for _, m := range members {
+		email := strings.ToLower(m.User.Email)
+		local, _, _ := strings.Cut(email, "@")
+		name := strings.ToLower(strings.ReplaceAll(m.User.Name, " ", ""))
+		if tokens[email] || tokens[local] || (name != "" && tokens[name]) {
+			ids = append(ids, m.UserID)
+		}
+	}
+	return ids, nil
+}
+
+// notifyMentions notifies members newly mentioned in the title/description of a task
+func notifyMentions(task models.Task, actorID uint, oldText, newText string) {
+	ids, err := mentionedMembers(task.ProjectID, oldText, newText)
+	if err != nil {
+		log.Printf("could not resolve mentions of task %d: %v", task.ID, err)
+		return
+	}
+	notifications.Notify(ids, notifications.Notice{
+		Type:      models.NotifMention,
+		ProjectID: task.ProjectID,
+		TaskID:    task.ID,
+		ActorID:   actorID,
+		Message:   fmt.Sprintf("You were mentioned in %q", task.Title),
+	})
+}
+
+// notifyAssigned tells a user they were assigned to a task
+func notifyAssigned(task models.Task, userID, actorID uint) {
+	notifications.Notify([]uint{userID}, notifications.Notice{
+		Type:      models.NotifAssigned,
+		ProjectID: task.ProjectID,
+		TaskID:    task.ID,
+		ActorID:   actorID,
+		Message:   fmt.Sprintf("You were assigned to %q", task.Title),
+	})
+}
+
+// taskAudience returns the users interested in changes of a task: its
+// watchers that are still members of the project
+func taskAudience(task models.Task) []uint {
+	var ids []uint
+	if err := initializers.DB.Model(&models.TaskWatcher{}).
+		Joins("JOIN project_members ON project_members.user_id = task_watchers.user_id AND project_members.project_id = ? AND project_members.deleted_at IS NULL", task.ProjectID).
+		Where("task_watchers.task_id = ?", task.ID).
+		Pluck("task_watchers.user_id", &ids).Error; err != nil {
+		log.Printf("could not load watchers of task %d: %v", task.ID, err)
+	}
+	return ids
+}
+
+// notifyStatusChange tells the audience of a task that its status changed
+func notifyStatusChange(task models.Task, actorID uint, from, to string) {
+	notifications.Notify(taskAudience(task), notifications.Notice{
+		Type:      models.NotifStatusChanged,
+		ProjectID: task.ProjectID,
+		TaskID:    task.ID,
+		ActorID:   actorID,
+		Message:   fmt.Sprintf("%q moved from %s to %s", task.Title, from, to),
+	})
+}
+
+// notifyTaskUpdated tells the audience of a task which fields changed
+func notifyTaskUpdated(task models.Task, actorID uint, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	notifications.Notify(taskAudience(task), notifications.Notice{
+		Type:      models.NotifTaskUpdated,
+		ProjectID: task.ProjectID,
+		TaskID:    task.ID,
+		ActorID:   actorID,
+		Message:   fmt.Sprintf("%q was updated (%s)", task.Title, strings.Join(fields, ", ")),
+	})
+}
+
+// notifyMembership tells a user they were added to or removed from a project
+func notifyMembership(projectID, userID, actorID uint, added bool) {
+	var project models.Project
+	if err := initializers.DB.Unscoped().Select("id", "name").First(&project, projectID).Error; err != nil {
+		log.Printf("could not load project %d: %v", projectID, err)
+		return
+	}
+	msg := fmt.Sprintf("You were added to project %q", project.Name)
+	if !added {
+		msg = fmt.Sprintf("You were removed from project %q", project.Name)
+	}
+	notifications.Notify([]uint{userID}, notifications.Notice{
+		Type:      models.NotifMembership,
+		ProjectID: projectID,
+		ActorID:   actorID,
+		Message:   msg,
+	})
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// GetNotifications lists the caller's notifications, newest first.
+// Query: unread=true, type, page, per_page.
+func GetNotifications(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultActivityPageSize)))
+	if err != nil || perPage < 1 || perPage > maxActivityPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid per_page"})
+		return
+	}
+
+	q := initializers.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		q = q.Where("read_at IS NULL")
+	}
+	if typ := c.Query("type"); typ != "" {
+		q = q.Where("type = ?", typ)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	var notifs []models.Notification
+	if err := q.Order("created_at DESC, id DESC").
+		Offset((page - 1) * perPage).Limit(perPage).
+		Find(&notifs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifs,
+		"page":          page,
+		"per_page":      perPage,
+		"total":         total,
+	})
+}
+
+// GetUnreadCount returns the number of unread notifications
+func GetUnreadCount(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	var n int64
+	if err := initializers.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&n).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": n})
+}
+
+// ownNotificationQuery scopes :notificationId to the caller
+func ownNotificationQuery(c *gin.Context) (*gorm.DB, bool) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return nil, false
+	}
+	nid64, err := strconv.ParseUint(c.Param("notificationId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return nil, false
+	}
+	return initializers.DB.Model(&models.Notification{}).Where("id = ? AND user_id = ?", uint(nid64), userID), true
+}
+
+// MarkNotificationRead marks one notification as read
+func MarkNotificationRead(c *gin.Context) {
+	q, ok := ownNotificationQuery(c)
+	if !ok {
+		return
+	}
+	res := q.Where("read_at IS NULL").Update("read_at", time.Now())
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update notification"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "notification read"})
+}
+
+// MarkAllNotificationsRead marks every unread notification of the caller as read
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	res := initializers.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "notifications read", "updated": res.RowsAffected})
+}
+
+// DeleteNotification deletes one notification of the caller
+func DeleteNotification(c *gin.Context) {
+	q, ok := ownNotificationQuery(c)
+	if !ok {
+		return
+	}
+	res := q.Delete(&models.Notification{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete notification"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "notification deleted"})
+}
+
+//
+// --------------------------- PREFERENCES ---------------------------
+//
+
+type notificationMuteItem struct {
+	Type      string `json:"type"`       // empty = every type
+	ProjectID *uint  `json:"project_id"` // null = every project
+}
+
+type notificationPrefsPayload struct {
+	Mutes []notificationMuteItem `json:"mutes"`
+}
+
+// GetNotificationPreferences returns the caller's mute rules
+func GetNotificationPreferences(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	var mutes []models.NotificationMute
+	if err := initializers.DB.Where("user_id = ?", userID).Order("id").Find(&mutes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mutes": mutes, "types": models.NotificationTypes})
+}
+
+// SetNotificationPreferences replaces the caller's mute rules
+func SetNotificationPreferences(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var body notificationPrefsPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	known := map[string]bool{"": true}
+	for _, t := range models.NotificationTypes {
+		known[t] = true
+	}
+	for _, m := range body.Mutes {
+		if !known[m.Type] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown notification type " + m.Type})
+			return
+		}
+		if m.ProjectID != nil {
+			isMember, err := IsProjectMember(*m.ProjectID, userID)
+			if err != nil || !isMember {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "not a member of project " + strconv.FormatUint(uint64(*m.ProjectID), 10)})
+				return
+			}
+		}
+	}
+
+	mutes := []models.NotificationMute{}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.NotificationMute{}).Error; err != nil {
+			return err
+		}
+		for _, m := range body.Mutes {
+			mute := models.NotificationMute{UserID: userID, Type: m.Type, ProjectID: m.ProjectID}
+			if err := tx.Create(&mute).Error; err != nil {
+				return err
+			}
+			mutes = append(mutes, mute)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mutes": mutes})
+}
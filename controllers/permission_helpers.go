@@ -2,6 +2,10 @@ package controllers
 
 import (
 	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
 
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
@@ -73,3 +77,24 @@ func RemoveProjectMember(projectID uint, userID uint) error {
 
 // ErrNotMember sentinel
 var ErrNotMember = errors.New("not a member")
+
+// requireProjectMemberParam parses :projectId and checks that the caller is a member
+func requireProjectMemberParam(c *gin.Context) (uint, bool) {
+	pid64, err := strconv.ParseUint(c.Param("projectId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return 0, false
+	}
+	projectID := uint(pid64)
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return 0, false
+	}
+	isMember, err := IsProjectMember(projectID, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+		return 0, false
+	}
+	return projectID, true
+}
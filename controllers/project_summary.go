@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// projectSummary : compteurs d'un projet pour le tableau de bord
+type projectSummary struct {
+	Tasks          int64            `json:"tasks"`
+	ByStatus       map[string]int64 `json:"by_status"`
+	ByPriority     map[string]int64 `json:"by_priority"`
+	Overdue        int64            `json:"overdue"`
+	Members        int64            `json:"members"`
+	MyOpenTasks    int64            `json:"my_open_tasks"`
+	LastActivityAt *time.Time       `json:"last_activity_at"`
+}
+
+type projectWithSummary struct {
+	models.Project
+	Summary projectSummary `json:"summary"`
+}
+
+// projectIncludes are the relations GetMyProjects preloads on request
+var projectIncludes = map[string]bool{"members": true, "tasks": true}
+
+// parseProjectIncludes reads ?include=members,tasks
+func parseProjectIncludes(raw string) (map[string]bool, error) {
+	out := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !projectIncludes[name] {
+			return nil, errors.New("include accepts members, tasks")
+		}
+		out[name] = true
+	}
+	return out, nil
+}
+
+// summarizeProjects computes the summaries of several projects with one
+// grouped query per counter
+func summarizeProjects(projects []models.Project, userID uint) (map[uint]*projectSummary, error) {
+	out := make(map[uint]*projectSummary, len(projects))
+	ids := make([]uint, 0, len(projects))
+	for _, p := range projects {
+		last := p.UpdatedAt
+		out[p.ID] = &projectSummary{
+			ByStatus:       map[string]int64{models.TaskStatusTodo: 0, models.TaskStatusDoing: 0, models.TaskStatusDone: 0},
+			ByPriority:     map[string]int64{models.TaskPriorityLow: 0, models.TaskPriorityMedium: 0, models.TaskPriorityHigh: 0},
+			LastActivityAt: &last,
+		}
+		ids = append(ids, p.ID)
+	}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	db := initializers.DB
+
+	var taskRows []struct {
+		ProjectID uint
+		Status    string
+		Priority  string
+		N         int64
+		Overdue   int64
+	}
+	if err := db.Model(&models.Task{}).
+		Select("project_id, status, priority, COUNT(*) AS n, "+
+			"COALESCE(SUM(CASE WHEN due_date < ? AND status <> ? THEN 1 ELSE 0 END), 0) AS overdue",
+			time.Now(), models.TaskStatusDone).
+		Where("project_id IN ?", ids).
+		Group("project_id, status, priority").
+		Scan(&taskRows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range taskRows {
+		s := out[r.ProjectID]
+		s.Tasks += r.N
+		s.ByStatus[r.Status] += r.N
+		s.ByPriority[r.Priority] += r.N
+		s.Overdue += r.Overdue
+	}
+
+	var memberRows []struct {
+		ProjectID uint
+		N         int64
+	}
+	if err := db.Model(&models.ProjectMember{}).
+		Select("project_id, COUNT(*) AS n").
+		Where("project_id IN ?", ids).
+		Group("project_id").
+		Scan(&memberRows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range memberRows {
+		out[r.ProjectID].Members = r.N
+	}
+
+	var mineRows []struct {
+		ProjectID uint
+		N         int64
+	}
+	if err := db.Model(&models.Task{}).
+		Select("tasks.project_id, COUNT(DISTINCT tasks.id) AS n").
+		Joins("JOIN task_assignees ON task_assignees.task_id = tasks.id AND task_assignees.deleted_at IS NULL").
+		Where("tasks.project_id IN ? AND task_assignees.user_id = ? AND tasks.status <> ?", ids, userID, models.TaskStatusDone).
+		Group("tasks.project_id").
+		Scan(&mineRows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range mineRows {
+		out[r.ProjectID].MyOpenTasks = r.N
+	}
+
+	var activityRows []struct {
+		ProjectID uint
+		At        time.Time
+	}
+	if err := db.Model(&models.TaskActivity{}).
+		Select("project_id, MAX(created_at) AS at").
+		Where("project_id IN ?", ids).
+		Group("project_id").
+		Scan(&activityRows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range activityRows {
+		s := out[r.ProjectID]
+		if r.At.After(*s.LastActivityAt) {
+			at := r.At
+			s.LastActivityAt = &at
+		}
+	}
+	return out, nil
+}
+
+// GetProjectSummary returns the dashboard counters of one project (members)
+func GetProjectSummary(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+	var project models.Project
+	if err := initializers.DB.First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+	summaries, err := summarizeProjects([]models.Project{project}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not compute summary"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project_id": projectID, "summary": summaries[projectID]})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
 )
 
 type createProjectPayload struct {
@@ -51,13 +52,19 @@ func CreateProject(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"project": project})
 }
 
-// GetMyProjects returns projects where user is a member (preload members and tasks count)
+// GetMyProjects returns projects where user is a member, each with its summary.
+// Members and tasks are only preloaded on request: ?include=members,tasks
 func GetMyProjects(c *gin.Context) {
 	userID, ok := getUserIDFromCtx(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
 		return
 	}
+	include, err := parseProjectIncludes(c.Query("include"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	db := initializers.DB
 
 	var memberships []models.ProjectMember
@@ -73,13 +80,33 @@ func GetMyProjects(c *gin.Context) {
 
 	var projects []models.Project
 	if len(projectIDs) > 0 {
-		if err := db.Where("id IN ?", projectIDs).Preload("Members").Preload("Tasks").Find(&projects).Error; err != nil {
+		q := db.Where("id IN ?", projectIDs)
+		if include["members"] {
+			q = q.Preload("Members")
+		}
+		if include["tasks"] {
+			q = q.Preload("Tasks", func(tx *gorm.DB) *gorm.DB {
+				return tx.Order(taskSortExpressions["status"]).Order("board_rank").Order("id")
+			})
+		}
+		if err := q.Order("id").Find(&projects).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load projects"})
 			return
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"projects": projects})
+	// compteurs calculés en SQL plutôt qu'à partir des tâches préchargées
+	summaries, err := summarizeProjects(projects, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not compute summaries"})
+		return
+	}
+	out := make([]projectWithSummary, 0, len(projects))
+	for _, p := range projects {
+		out = append(out, projectWithSummary{Project: p, Summary: *summaries[p.ID]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"projects": out})
 }
 
 // GetProjectDetail returns project if user is member
@@ -115,11 +142,164 @@ func GetProjectDetail(c *gin.Context) {
 
 	db := initializers.DB
 	var project models.Project
-	if err := db.Preload("Members").Preload("Tasks.Assignees").First(&project, projectID).Error; err != nil {
+	tasksInBoardOrder := func(tx *gorm.DB) *gorm.DB {
+		return tx.Order(taskSortExpressions["status"]).Order("board_rank").Order("id")
+	}
+	if err := db.Preload("Members").Preload("Tasks", tasksInBoardOrder).Preload("Tasks.Assignees").First(&project, projectID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"project": project})
+
+	setETag(c, project.Version)
+
+	// WIP : compteurs actuels vs limites
+	wip, err := computeWipUsage(projectID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"project": project, "warning": "could not compute WIP usage"})
+		return
+	}
+	// sprints en cours / planifiés avec leur avancement
+	sprints, err := openSprintsProgress(projectID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"project": project, "wip": wip, "warning": "could not compute sprint progress"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project, "wip": wip, "sprints": sprints})
+}
+
+type updateProjectPayload struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Version     *uint   `json:"version"` // when no If-Match header is sent
+
+	EstimateScale *string `json:"estimate_scale"` // FIBONACCI, TSHIRT or HOURS
+}
+
+// respondProjectConflict answers 412 with the current project so the client can merge
+func respondProjectConflict(c *gin.Context, projectID uint) {
+	var current models.Project
+	if err := initializers.DB.First(&current, projectID).Error; err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version conflict"})
+		return
+	}
+	setETag(c, current.Version)
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version conflict", "project": current})
+}
+
+// UpdateProject: only owner can rename / change description, with optimistic locking
+func UpdateProject(c *gin.Context) {
+	pidStr := c.Param("projectId")
+	pid64, err := strconv.ParseUint(pidStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	projectID := uint(pid64)
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var project models.Project
+	if err := initializers.DB.First(&project, projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+
+	isOwner, err := IsProjectOwner(projectID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	if !isOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owner can update project"})
+		return
+	}
+
+	var body updateProjectPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expected, ok := requireVersion(c, body.Version)
+	if !ok {
+		return
+	}
+	if project.Version != expected {
+		respondProjectConflict(c, projectID)
+		return
+	}
+
+	updated := map[string]interface{}{}
+	if body.Name != nil {
+		if *body.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		updated["name"] = *body.Name
+	}
+	if body.Description != nil {
+		updated["description"] = *body.Description
+	}
+	if body.EstimateScale != nil {
+		if !estimateScales[*body.EstimateScale] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "estimate_scale must be FIBONACCI, TSHIRT or HOURS"})
+			return
+		}
+		updated["estimate_scale"] = *body.EstimateScale
+	}
+	if len(updated) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+	updated["version"] = gorm.Expr("version + 1")
+
+	// changement d'échelle : les estimations sont reprises dans la nouvelle
+	// échelle quand elles y existent, effacées sinon
+	cleared := 0
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Project{}).
+			Where("id = ? AND version = ?", projectID, expected).
+			Updates(updated)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		if body.EstimateScale == nil || *body.EstimateScale == project.EstimateScale {
+			return nil
+		}
+		n, err := remapEstimates(tx, projectID, *body.EstimateScale)
+		cleared = n
+		return err
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondProjectConflict(c, projectID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update project"})
+		return
+	}
+
+	if err := initializers.DB.First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "project updated", "warning": "updated but failed to reload"})
+		return
+	}
+	emitProjectEvent(projectID, userID, realtime.ProjectUpdated, gin.H{"project": project})
+
+	setETag(c, project.Version)
+	resp := gin.H{"project": project}
+	if cleared > 0 {
+		resp["estimates_cleared"] = cleared
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // AddMember: only OWNER can add
@@ -166,6 +346,9 @@ func AddMember(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not add member"})
 		return
 	}
+	emitProjectEvent(projectID, userID, realtime.MemberAdded, gin.H{"user_id": body.UserID, "role": role})
+	notifyMembership(projectID, body.UserID, userID, true)
+
 	c.JSON(http.StatusOK, gin.H{"message": "member added"})
 }
 
@@ -216,6 +399,8 @@ func removeMemberHandlerCommon(c *gin.Context, projectID uint, targetUserID uint
 		c.JSON(http.StatusNotFound, gin.H{"error": "member not found for that project"})
 		return
 	}
+	emitProjectEvent(projectID, callerID, realtime.MemberRemoved, gin.H{"user_id": targetUserID})
+	notifyMembership(projectID, targetUserID, callerID, false)
 
 	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
 }
@@ -238,7 +423,7 @@ func RemoveMember(c *gin.Context) {
 	removeMemberHandlerCommon(c, projectID, body.UserID)
 }
 
-// DeleteProject: only owner can delete
+// DeleteProject: only owner can delete, with the version it last read
 func DeleteProject(c *gin.Context) {
 	pidStr := c.Param("projectId")
 	pid64, err := strconv.ParseUint(pidStr, 10, 64)
@@ -263,10 +448,23 @@ func DeleteProject(c *gin.Context) {
 		return
 	}
 
-	if err := initializers.DB.Delete(&models.Project{}, projectID).Error; err != nil {
+	// optimistic locking: If-Match header or ?version=
+	expected, ok := requireVersion(c, queryVersion(c))
+	if !ok {
+		return
+	}
+
+	res := initializers.DB.Where("version = ?", expected).Delete(&models.Project{}, projectID)
+	if res.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete project"})
 		return
 	}
+	if res.RowsAffected == 0 {
+		respondProjectConflict(c, projectID)
+		return
+	}
+	emitProjectEvent(projectID, userID, realtime.ProjectDeleted, gin.H{"project_id": projectID})
+
 	c.JSON(http.StatusOK, gin.H{"message": "project deleted"})
 }
 
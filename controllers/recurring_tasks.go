@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/recurrence"
+)
+
+// checkRecurrenceMode validates a mode, empty meaning ON_DONE
+func checkRecurrenceMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return models.RecurrenceOnDone, nil
+	case models.RecurrenceOnDone, models.RecurrenceSchedule:
+		return mode, nil
+	}
+	return "", errors.New("recurrence_mode must be ON_DONE or SCHEDULE")
+}
+
+// recurrenceColumns validates a rule and returns the task columns that
+// start a new series at due. An empty rule removes the recurrence.
+func recurrenceColumns(rule, mode string, due *time.Time) (map[string]interface{}, error) {
+	if rule == "" {
+		return map[string]interface{}{
+			"recurrence": "", "recurrence_mode": "", "recurrence_start": nil, "recurrence_index": 0,
+		}, nil
+	}
+	r, err := recurrence.Parse(rule)
+	if err != nil {
+		return nil, err
+	}
+	if due == nil {
+		return nil, errors.New("a recurring task needs a due_date")
+	}
+	if mode, err = checkRecurrenceMode(mode); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"recurrence": r.String(), "recurrence_mode": mode, "recurrence_start": *due, "recurrence_index": 1,
+	}, nil
+}
+
+// spawnNextOccurrence creates the next occurrence of a recurring task:
+// same title, description, priority and assignees, due date shifted by
+// the rule. Returns nil when the series is over (its mode is then
+// cleared) or the next occurrence already exists.
+func spawnNextOccurrence(taskID uint) (*models.Task, error) {
+	var next *models.Task
+	var assignees []uint
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// la ligne reste verrouillée jusqu'au commit : une seule occurrence suivante
+		var task models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, taskID).Error; err != nil {
+			return err
+		}
+		if task.Recurrence == "" || task.RecurrenceNextID != nil || task.DueDate == nil || task.RecurrenceStart == nil {
+			return nil
+		}
+		rule, err := recurrence.Parse(task.Recurrence)
+		if err != nil {
+			return err
+		}
+		due, index, ok := rule.Next(*task.RecurrenceStart, *task.DueDate)
+		if !ok {
+			// fin de la série (COUNT/UNTIL) : plus rien à déclencher
+			return tx.Model(&models.Task{}).Where("id = ?", task.ID).
+				Updates(map[string]interface{}{"recurrence_mode": "", "version": gorm.Expr("version + 1")}).Error
+		}
+
+		rank, err := rankAtEndOfColumn(tx, task.ProjectID, models.TaskStatusTodo)
+		if err != nil {
+			return err
+		}
+		parent := task.RecurrenceParentID
+		if parent == nil {
+			parent = &task.ID
+		}
+		t := models.Task{
+			ProjectID:          task.ProjectID,
+			Title:              task.Title,
+			Description:        task.Description,
+			Status:             models.TaskStatusTodo,
+			Priority:           task.Priority,
+			DueDate:            &due,
+			Rank:               rank,
+			CreatorID:          task.CreatorID,
+			Recurrence:         task.Recurrence,
+			RecurrenceMode:     task.RecurrenceMode,
+			RecurrenceStart:    task.RecurrenceStart,
+			RecurrenceIndex:    index,
+			RecurrenceParentID: parent,
+		}
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.TaskAssignee{}).Where("task_id = ?", task.ID).Pluck("user_id", &assignees).Error; err != nil {
+			return err
+		}
+		for _, uid := range assignees {
+			if err := tx.Create(&models.TaskAssignee{TaskID: t.ID, UserID: uid}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).
+			UpdateColumn("recurrence_next_id", t.ID).Error; err != nil {
+			return err
+		}
+		next = &t
+		return nil
+	})
+	if err != nil || next == nil {
+		return nil, err
+	}
+
+	// occurrence créée par le système : l'historique l'attribue au créateur de
+	// la série (actor_id est une clé étrangère vers users, 0 serait refusé)
+	recordTaskActivity(initializers.DB, *next, next.CreatorID, models.ActivityCreated, "", "", next.Title)
+	watchTask(next.ID, next.CreatorID)
+	for _, uid := range assignees {
+		watchTask(next.ID, uid)
+		notifyAssigned(*next, uid, 0)
+	}
+	initializers.DB.Preload("Assignees.User").First(next, next.ID)
+	emitProjectEvent(next.ProjectID, 0, realtime.TaskCreated, gin.H{"task": next})
+	return next, nil
+}
+
+// afterTaskDone spawns the next occurrence of an ON_DONE recurring task
+func afterTaskDone(task models.Task) {
+	if task.Status != models.TaskStatusDone || task.Recurrence == "" || task.RecurrenceMode != models.RecurrenceOnDone {
+		return
+	}
+	if _, err := spawnNextOccurrence(task.ID); err != nil {
+		log.Printf("could not create next occurrence of task %d: %v", task.ID, err)
+	}
+}
+
+// SpawnScheduledOccurrences creates the next occurrence of SCHEDULE
+// recurring tasks whose due date has passed, done or not
+func SpawnScheduledOccurrences(now time.Time) error {
+	var ids []uint
+	if err := initializers.DB.Model(&models.Task{}).
+		Where("recurrence <> '' AND recurrence_mode = ? AND recurrence_next_id IS NULL AND due_date <= ?", models.RecurrenceSchedule, now).
+		Order("due_date").
+		Limit(100).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := spawnNextOccurrence(id); err != nil {
+			log.Printf("could not create next occurrence of task %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// StartRecurrenceScheduler runs SpawnScheduledOccurrences every interval
+func StartRecurrenceScheduler(interval time.Duration) {
+	go func() {
+		for {
+			if err := SpawnScheduledOccurrences(time.Now()); err != nil {
+				log.Printf("recurring tasks: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"math"
+	"time"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// doneTask is a task currently DONE with the time it was last moved to DONE
+type doneTask struct {
+	ID          uint
+	Title       string
+	Priority    string
+	SprintID    *uint
+	StoryPoints *float64
+	CreatedAt   time.Time
+	DoneAt      time.Time
+}
+
+func (t doneTask) points() float64 {
+	if t.StoryPoints == nil {
+		return 0
+	}
+	return *t.StoryPoints
+}
+
+// doneTasks returns the DONE tasks of a project. The completion time comes
+// from the status history; tasks done before history existed use updated_at.
+func doneTasks(projectID uint) ([]doneTask, error) {
+	var tasks []models.Task
+	if err := initializers.DB.
+		Select("id", "title", "priority", "sprint_id", "story_points", "created_at", "updated_at").
+		Where("project_id = ? AND status = ?", projectID, models.TaskStatusDone).
+		Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		TaskID uint
+		DoneAt time.Time
+	}
+	if err := initializers.DB.Model(&models.TaskActivity{}).
+		Select("task_id, MAX(created_at) AS done_at").
+		Where("project_id = ? AND type = ? AND field = ? AND new_value = ?",
+			projectID, models.ActivityUpdated, "status", models.TaskStatusDone).
+		Group("task_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	doneAt := make(map[uint]time.Time, len(rows))
+	for _, r := range rows {
+		doneAt[r.TaskID] = r.DoneAt
+	}
+
+	out := make([]doneTask, 0, len(tasks))
+	for _, t := range tasks {
+		at, ok := doneAt[t.ID]
+		if !ok {
+			at = t.UpdatedAt
+		}
+		out = append(out, doneTask{
+			ID:          t.ID,
+			Title:       t.Title,
+			Priority:    t.Priority,
+			SprintID:    t.SprintID,
+			StoryPoints: t.StoryPoints,
+			CreatedAt:   t.CreatedAt,
+			DoneAt:      at,
+		})
+	}
+	return out, nil
+}
+
+// startOfWeek returns the Monday 00:00 of the week of t
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// round1 rounds to one decimal
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+type savedViewPayload struct {
+	Name      string `json:"name" binding:"required"`
+	ProjectID *uint  `json:"project_id"`
+	Shared    bool   `json:"shared"`
+	Filter    string `json:"filter"`
+	SortBy    string `json:"sort_by"`
+	SortDesc  bool   `json:"sort_desc"`
+	GroupBy   string `json:"group_by"`
+	Columns   string `json:"columns"` // comma separated, e.g. "title,status,due_date"
+	Layout    string `json:"layout"`  // list, board or calendar
+}
+
+// viewColumns lists the task columns a view can display
+var viewColumns = map[string]bool{
+	"id": true, "title": true, "description": true, "status": true, "priority": true,
+	"due_date": true, "assignees": true, "creator_id": true, "project_id": true,
+	"created_at": true, "updated_at": true,
+}
+
+// validateSavedView checks the payload and normalises columns and layout
+func validateSavedView(body *savedViewPayload) error {
+	if _, err := parseTaskFilter(body.Filter); err != nil {
+		return err
+	}
+	if _, ok := taskSortExpressions[body.SortBy]; body.SortBy != "" && !ok {
+		return fmt.Errorf("invalid sort_by %q", body.SortBy)
+	}
+	if body.GroupBy != "" && !taskGroupKeys[body.GroupBy] {
+		return fmt.Errorf("invalid group_by %q", body.GroupBy)
+	}
+
+	cols := splitFilterValues(body.Columns)
+	for _, col := range cols {
+		if !viewColumns[col] {
+			return fmt.Errorf("invalid column %q", col)
+		}
+	}
+	body.Columns = strings.Join(cols, ",")
+
+	switch body.Layout {
+	case "":
+		body.Layout = models.ViewLayoutList
+	case models.ViewLayoutList, models.ViewLayoutBoard, models.ViewLayoutCalendar:
+	default:
+		return fmt.Errorf("invalid layout %q", body.Layout)
+	}
+
+	if body.Shared && body.ProjectID == nil {
+		return errors.New("a shared view needs a project_id")
+	}
+	return nil
+}
+
+// canReadView: the owner, or any project member when the view is shared
+func canReadView(view models.SavedView, userID uint) bool {
+	if view.OwnerID == userID {
+		return true
+	}
+	if !view.Shared || view.ProjectID == nil {
+		return false
+	}
+	isMember, err := IsProjectMember(*view.ProjectID, userID)
+	return err == nil && isMember
+}
+
+// loadViewFromParam reads :viewId and loads the view, writing the error response itself
+func loadViewFromParam(c *gin.Context) (models.SavedView, bool) {
+	var view models.SavedView
+	vid64, err := strconv.ParseUint(c.Param("viewId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid view id"})
+		return view, false
+	}
+	if err := initializers.DB.First(&view, uint(vid64)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "view not found"})
+			return view, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return view, false
+	}
+	return view, true
+}
+
+// CreateSavedView: any user can save a view; project views need membership
+func CreateSavedView(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var body savedViewPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSavedView(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.ProjectID != nil {
+		isMember, err := IsProjectMember(*body.ProjectID, userID)
+		if err != nil || !isMember {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+			return
+		}
+	}
+
+	view := models.SavedView{
+		Name:      body.Name,
+		OwnerID:   userID,
+		ProjectID: body.ProjectID,
+		Shared:    body.Shared,
+		Filter:    body.Filter,
+		SortBy:    body.SortBy,
+		SortDesc:  body.SortDesc,
+		GroupBy:   body.GroupBy,
+		Columns:   body.Columns,
+		Layout:    body.Layout,
+	}
+	if err := initializers.DB.Create(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create view"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"view": view})
+}
+
+// GetSavedViews returns the caller's views plus views shared in their projects.
+// ?project_id= restricts the list to one project.
+func GetSavedViews(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	q := initializers.DB.Where(
+		"owner_id = ? OR (shared = ? AND project_id IN (SELECT project_id FROM project_members WHERE user_id = ? AND deleted_at IS NULL))",
+		userID, true, userID,
+	)
+	if pidStr := c.Query("project_id"); pidStr != "" {
+		pid64, err := strconv.ParseUint(pidStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+			return
+		}
+		q = q.Where("project_id = ?", uint(pid64))
+	}
+
+	var views []models.SavedView
+	if err := q.Order("name").Find(&views).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load views"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"views": views})
+}
+
+// GetSavedView returns one view if the caller can read it
+func GetSavedView(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	view, ok := loadViewFromParam(c)
+	if !ok {
+		return
+	}
+	if !canReadView(view, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to read this view"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"view": view})
+}
+
+// UpdateSavedView: only the owner of the view can change it
+func UpdateSavedView(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	view, ok := loadViewFromParam(c)
+	if !ok {
+		return
+	}
+	if view.OwnerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can update a view"})
+		return
+	}
+
+	var body savedViewPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSavedView(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.ProjectID != nil {
+		isMember, err := IsProjectMember(*body.ProjectID, userID)
+		if err != nil || !isMember {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+			return
+		}
+	}
+
+	view.Name = body.Name
+	view.ProjectID = body.ProjectID
+	view.Shared = body.Shared
+	view.Filter = body.Filter
+	view.SortBy = body.SortBy
+	view.SortDesc = body.SortDesc
+	view.GroupBy = body.GroupBy
+	view.Columns = body.Columns
+	view.Layout = body.Layout
+	if err := initializers.DB.Save(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update view"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"view": view})
+}
+
+// DeleteSavedView: the owner, or the project owner for a shared view
+func DeleteSavedView(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	view, ok := loadViewFromParam(c)
+	if !ok {
+		return
+	}
+	if view.OwnerID != userID {
+		allowed := false
+		if view.Shared && view.ProjectID != nil {
+			isOwner, err := IsProjectOwner(*view.ProjectID, userID)
+			allowed = err == nil && isOwner
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the view owner or project owner can delete a view"})
+			return
+		}
+	}
+
+	if err := initializers.DB.Delete(&models.SavedView{}, view.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete view"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "view deleted"})
+}
+
+// RunSavedView executes the view against tasks, limited to the caller's projects
+func RunSavedView(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	view, ok := loadViewFromParam(c)
+	if !ok {
+		return
+	}
+	if !canReadView(view, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to read this view"})
+		return
+	}
+
+	filter, err := parseTaskFilter(view.Filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stored filter is invalid: " + err.Error()})
+		return
+	}
+
+	q := initializers.DB.Model(&models.Task{})
+	if view.ProjectID != nil {
+		// the owner may have left the project since saving the view
+		isMember, err := IsProjectMember(*view.ProjectID, userID)
+		if err != nil || !isMember {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+			return
+		}
+		q = q.Where("tasks.project_id = ?", *view.ProjectID)
+	} else {
+		q = q.Where("tasks.project_id IN (SELECT project_id FROM project_members WHERE user_id = ? AND deleted_at IS NULL)", userID)
+	}
+	q = applyTaskFilter(q, filter, userID)
+	q = applyTaskSort(q, view.SortBy, view.SortDesc)
+
+	var tasks []models.Task
+	if err := q.Preload("Assignees.User").Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+
+	resp := gin.H{"view": view, "tasks": tasks}
+	if view.GroupBy != "" {
+		resp["groups"] = groupTasks(tasks, view.GroupBy)
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,472 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+)
+
+type sprintPayload struct {
+	Name      *string    `json:"name"`
+	Goal      *string    `json:"goal"`
+	StartDate *time.Time `json:"start_date"`
+	EndDate   *time.Time `json:"end_date"`
+}
+
+type sprintProgress struct {
+	Total    int64   `json:"total"`
+	Todo     int64   `json:"todo"`
+	Doing    int64   `json:"doing"`
+	Done     int64   `json:"done"`
+	Percent  float64 `json:"percent"`   // tâches DONE / total
+	DaysLeft *int    `json:"days_left"` // jusqu'à end_date, null si pas de date
+}
+
+// sprintWithProgress : sprint + avancement, à plat dans le JSON
+type sprintWithProgress struct {
+	models.Sprint
+	Progress sprintProgress `json:"progress"`
+}
+
+// computeSprintProgress counts the tasks of a sprint per status
+func computeSprintProgress(s models.Sprint) (sprintProgress, error) {
+	var p sprintProgress
+	var rows []struct {
+		Status string
+		N      int64
+	}
+	if err := initializers.DB.Model(&models.Task{}).
+		Select("status, COUNT(*) AS n").
+		Where("sprint_id = ?", s.ID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return p, err
+	}
+	for _, r := range rows {
+		p.Total += r.N
+		switch r.Status {
+		case models.TaskStatusDone:
+			p.Done += r.N
+		case models.TaskStatusDoing:
+			p.Doing += r.N
+		default:
+			p.Todo += r.N
+		}
+	}
+	if p.Total > 0 {
+		p.Percent = math.Round(float64(p.Done)*1000/float64(p.Total)) / 10
+	}
+	if s.EndDate != nil && s.State != models.SprintClosed {
+		days := int(math.Ceil(time.Until(*s.EndDate).Hours() / 24))
+		if days < 0 {
+			days = 0
+		}
+		p.DaysLeft = &days
+	}
+	return p, nil
+}
+
+// sprintsWithProgress adds the progress of each sprint
+func sprintsWithProgress(sprints []models.Sprint) ([]sprintWithProgress, error) {
+	out := make([]sprintWithProgress, 0, len(sprints))
+	for _, s := range sprints {
+		p, err := computeSprintProgress(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sprintWithProgress{Sprint: s, Progress: p})
+	}
+	return out, nil
+}
+
+// openSprintsProgress : sprints non clos d'un projet (actif en premier), pour le détail projet
+func openSprintsProgress(projectID uint) ([]sprintWithProgress, error) {
+	var sprints []models.Sprint
+	if err := initializers.DB.
+		Where("project_id = ? AND state <> ?", projectID, models.SprintClosed).
+		Order(sprintOrder).
+		Find(&sprints).Error; err != nil {
+		return nil, err
+	}
+	return sprintsWithProgress(sprints)
+}
+
+// sprintOrder : actif, puis planifiés par date de début, puis clos
+const sprintOrder = "CASE state WHEN 'ACTIVE' THEN 0 WHEN 'PLANNED' THEN 1 ELSE 2 END, start_date IS NULL, start_date, id"
+
+// validateSprintTask checks that a task can join sprintID (0 = leave its sprint)
+func validateSprintTask(projectID, sprintID uint) error {
+	if sprintID == 0 {
+		return nil
+	}
+	var s models.Sprint
+	if err := initializers.DB.Where("id = ? AND project_id = ?", sprintID, projectID).First(&s).Error; err != nil {
+		return errors.New("sprint not found in this project")
+	}
+	if s.State == models.SprintClosed {
+		return errors.New("sprint is closed")
+	}
+	return nil
+}
+
+// loadProjectSprint parses :projectId/:sprintId; owner=true requires the project owner
+func loadProjectSprint(c *gin.Context, owner bool) (models.Sprint, uint, bool) {
+	var s models.Sprint
+	var projectID uint
+	var ok bool
+	if owner {
+		projectID, ok = requireProjectOwnerParam(c, "only owner can manage sprints")
+	} else {
+		projectID, ok = requireProjectMemberParam(c)
+	}
+	if !ok {
+		return s, 0, false
+	}
+	sid64, err := strconv.ParseUint(c.Param("sprintId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint id"})
+		return s, 0, false
+	}
+	if err := initializers.DB.Where("id = ? AND project_id = ?", uint(sid64), projectID).First(&s).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sprint not found"})
+		return s, 0, false
+	}
+	userID, _ := getUserIDFromCtx(c)
+	return s, userID, true
+}
+
+func checkSprintDates(start, end *time.Time) bool {
+	return start == nil || end == nil || !end.Before(*start)
+}
+
+// GetSprints lists the sprints of a project with their progress. Query: state
+func GetSprints(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	q := initializers.DB.Where("project_id = ?", projectID)
+	if state := c.Query("state"); state != "" {
+		q = q.Where("state = ?", state)
+	}
+	var sprints []models.Sprint
+	if err := q.Order(sprintOrder).Find(&sprints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load sprints"})
+		return
+	}
+	out, err := sprintsWithProgress(sprints)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not compute progress"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sprints": out})
+}
+
+// CreateSprint plans a new sprint (owner)
+func CreateSprint(c *gin.Context) {
+	projectID, ok := requireProjectOwnerParam(c, "only owner can manage sprints")
+	if !ok {
+		return
+	}
+	var body sprintPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Name == nil || *body.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if !checkSprintDates(body.StartDate, body.EndDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date is before start_date"})
+		return
+	}
+
+	s := models.Sprint{
+		ProjectID: projectID,
+		Name:      *body.Name,
+		StartDate: body.StartDate,
+		EndDate:   body.EndDate,
+		State:     models.SprintPlanned,
+	}
+	if body.Goal != nil {
+		s.Goal = *body.Goal
+	}
+	if err := initializers.DB.Create(&s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create sprint"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"sprint": s})
+}
+
+// GetSprint returns a sprint with its progress and tasks in board order
+func GetSprint(c *gin.Context) {
+	s, _, ok := loadProjectSprint(c, false)
+	if !ok {
+		return
+	}
+	p, err := computeSprintProgress(s)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not compute progress"})
+		return
+	}
+	var tasks []models.Task
+	if err := initializers.DB.Preload("Assignees.User").
+		Where("sprint_id = ?", s.ID).
+		Order(taskSortExpressions["status"]).Order("board_rank").Order("id").
+		Find(&tasks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sprint": sprintWithProgress{Sprint: s, Progress: p}, "tasks": tasks})
+}
+
+// UpdateSprint changes name, goal or dates of a sprint that is not closed (owner)
+func UpdateSprint(c *gin.Context) {
+	s, _, ok := loadProjectSprint(c, true)
+	if !ok {
+		return
+	}
+	if s.State == models.SprintClosed {
+		c.JSON(http.StatusConflict, gin.H{"error": "sprint is closed"})
+		return
+	}
+	var body sprintPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated := map[string]interface{}{}
+	if body.Name != nil {
+		if *body.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		updated["name"] = *body.Name
+	}
+	if body.Goal != nil {
+		updated["goal"] = *body.Goal
+	}
+	start, end := s.StartDate, s.EndDate
+	if body.StartDate != nil {
+		updated["start_date"] = body.StartDate
+		start = body.StartDate
+	}
+	if body.EndDate != nil {
+		updated["end_date"] = body.EndDate
+		end = body.EndDate
+	}
+	if !checkSprintDates(start, end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date is before start_date"})
+		return
+	}
+	if len(updated) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+	if err := initializers.DB.Model(&s).Updates(updated).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update sprint"})
+		return
+	}
+	initializers.DB.First(&s, s.ID)
+	c.JSON(http.StatusOK, gin.H{"sprint": s})
+}
+
+// DeleteSprint deletes a planned sprint; its tasks go back to the backlog (owner)
+func DeleteSprint(c *gin.Context) {
+	s, _, ok := loadProjectSprint(c, true)
+	if !ok {
+		return
+	}
+	if s.State != models.SprintPlanned {
+		c.JSON(http.StatusConflict, gin.H{"error": "only planned sprints can be deleted"})
+		return
+	}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("sprint_id = ?", s.ID).Updates(map[string]interface{}{"sprint_id": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&s).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete sprint"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "sprint deleted"})
+}
+
+// StartSprint makes a planned sprint the active one (owner)
+func StartSprint(c *gin.Context) {
+	s, userID, ok := loadProjectSprint(c, true)
+	if !ok {
+		return
+	}
+	if s.State != models.SprintPlanned {
+		c.JSON(http.StatusConflict, gin.H{"error": "only planned sprints can be started"})
+		return
+	}
+
+	now := time.Now()
+	updated := map[string]interface{}{"state": models.SprintActive, "started_at": now}
+	if s.StartDate == nil {
+		updated["start_date"] = now
+	}
+	// un seul sprint actif : le verrou sur le projet sérialise les démarrages
+	var active models.Sprint
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Project{}, s.ProjectID).Error; err != nil {
+			return err
+		}
+		err := tx.Where("project_id = ? AND state = ?", s.ProjectID, models.SprintActive).First(&active).Error
+		if err == nil {
+			return errActiveSprint
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		res := tx.Model(&models.Sprint{}).
+			Where("id = ? AND state = ?", s.ID, models.SprintPlanned).
+			Updates(updated)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errSprintChanged
+		}
+		return nil
+	})
+	if errors.Is(err, errActiveSprint) {
+		c.JSON(http.StatusConflict, gin.H{"error": "another sprint is active", "active_sprint": active})
+		return
+	}
+	if errors.Is(err, errSprintChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "sprint was changed by someone else"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start sprint"})
+		return
+	}
+	// périmètre engagé au démarrage, pour la vélocité
+	var scope struct {
+		Points float64
+		Tasks  int
+	}
+	if err := initializers.DB.Model(&models.Task{}).
+		Select("COALESCE(SUM(story_points), 0) AS points, COUNT(*) AS tasks").
+		Where("sprint_id = ?", s.ID).
+		Scan(&scope).Error; err == nil {
+		initializers.DB.Model(&models.Sprint{}).Where("id = ?", s.ID).
+			Updates(map[string]interface{}{"committed_points": scope.Points, "committed_tasks": scope.Tasks})
+	}
+	initializers.DB.First(&s, s.ID)
+	emitProjectEvent(s.ProjectID, userID, realtime.SprintStarted, gin.H{"sprint": s})
+	c.JSON(http.StatusOK, gin.H{"sprint": s})
+}
+
+type closeSprintPayload struct {
+	NextSprintID *uint `json:"next_sprint_id"` // sinon : prochain sprint planifié, ou backlog
+}
+
+// CloseSprint closes the active sprint and carries its unfinished tasks
+// over to the next sprint (owner)
+func CloseSprint(c *gin.Context) {
+	s, userID, ok := loadProjectSprint(c, true)
+	if !ok {
+		return
+	}
+	if s.State != models.SprintActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "only the active sprint can be closed"})
+		return
+	}
+	var body closeSprintPayload
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// sprint qui reçoit les tâches non terminées
+	var next *models.Sprint
+	if body.NextSprintID != nil {
+		var n models.Sprint
+		if err := initializers.DB.Where("id = ? AND project_id = ? AND state = ?", *body.NextSprintID, s.ProjectID, models.SprintPlanned).
+			First(&n).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "next_sprint_id must be a planned sprint of this project"})
+			return
+		}
+		next = &n
+	} else {
+		var n models.Sprint
+		err := initializers.DB.Where("project_id = ? AND state = ?", s.ProjectID, models.SprintPlanned).
+			Order("start_date IS NULL, start_date, id").First(&n).Error
+		if err == nil {
+			next = &n
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+	}
+
+	var carried []models.Task
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Sprint{}).
+			Where("id = ? AND state = ?", s.ID, models.SprintActive).
+			Updates(map[string]interface{}{"state": models.SprintClosed, "closed_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errSprintChanged
+		}
+		if err := tx.Where("sprint_id = ? AND status <> ?", s.ID, models.TaskStatusDone).Find(&carried).Error; err != nil {
+			return err
+		}
+		var target interface{}
+		if next != nil {
+			target = next.ID
+		}
+		return tx.Model(&models.Task{}).
+			Where("sprint_id = ? AND status <> ?", s.ID, models.TaskStatusDone).
+			Updates(map[string]interface{}{"sprint_id": target, "version": gorm.Expr("version + 1")}).Error
+	})
+	if errors.Is(err, errSprintChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "sprint was changed by someone else"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not close sprint"})
+		return
+	}
+
+	newValue := ""
+	var nextID *uint
+	if next != nil {
+		nextID = &next.ID
+		newValue = formatActivityValue(next.ID)
+	}
+	for _, t := range carried {
+		recordTaskActivity(initializers.DB, t, userID, models.ActivityUpdated, "sprint_id", formatActivityValue(s.ID), newValue)
+	}
+
+	initializers.DB.First(&s, s.ID)
+	emitProjectEvent(s.ProjectID, userID, realtime.SprintClosed, gin.H{"sprint": s, "carried_over": len(carried), "next_sprint_id": nextID})
+	c.JSON(http.StatusOK, gin.H{"sprint": s, "carried_over": len(carried), "next_sprint_id": nextID})
+}
+
+var (
+	errSprintChanged = errors.New("sprint changed")
+	errActiveSprint  = errors.New("another sprint is active")
+)
@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// taskFilter is the parsed form of a filter expression, e.g.
+//
+//	status:TODO,DOING priority:HIGH assignee:me sprint:active overdue:true login bug
+//
+// Terms are separated by spaces and combined with AND, the values of one term
+// are separated by commas and combined with OR. Words without a key are
+// searched in title and description.
+type taskFilter struct {
+	Statuses   []string
+	Priorities []string
+	Assignees  []string // user ids, "me" or "none"
+	Creators   []string // user ids or "me"
+	Sprints    []string // sprint ids, "active" or "none"
+	Overdue    *bool
+	DueBefore  *time.Time
+	DueAfter   *time.Time
+	Text       []string
+}
+
+const filterDateLayout = "2006-01-02"
+
+func parseTaskFilter(expr string) (taskFilter, error) {
+	var f taskFilter
+	for _, term := range strings.Fields(expr) {
+		key, value, hasKey := strings.Cut(term, ":")
+		if !hasKey {
+			f.Text = append(f.Text, term)
+			continue
+		}
+		values := splitFilterValues(value)
+		if len(values) == 0 {
+			return f, fmt.Errorf("empty value for %q", key)
+		}
+
+		switch strings.ToLower(key) {
+		case "status":
+			for _, v := range values {
+				f.Statuses = append(f.Statuses, strings.ToUpper(v))
+			}
+		case "priority":
+			for _, v := range values {
+				f.Priorities = append(f.Priorities, strings.ToUpper(v))
+			}
+		case "assignee":
+			if err := checkUserRefs(values, true); err != nil {
+				return f, err
+			}
+			f.Assignees = append(f.Assignees, values...)
+		case "creator":
+			if err := checkUserRefs(values, false); err != nil {
+				return f, err
+			}
+			f.Creators = append(f.Creators, values...)
+		case "sprint":
+			for _, v := range values {
+				if v == "active" || v == "none" {
+					continue
+				}
+				if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+					return f, fmt.Errorf("invalid sprint reference %q", v)
+				}
+			}
+			f.Sprints = append(f.Sprints, values...)
+		case "overdue":
+			b, err := strconv.ParseBool(values[0])
+			if err != nil {
+				return f, fmt.Errorf("invalid overdue value %q", values[0])
+			}
+			f.Overdue = &b
+		case "due_before", "due_after":
+			d, err := time.Parse(filterDateLayout, values[0])
+			if err != nil {
+				return f, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", values[0])
+			}
+			if strings.ToLower(key) == "due_before" {
+				f.DueBefore = &d
+			} else {
+				f.DueAfter = &d
+			}
+		case "text":
+			f.Text = append(f.Text, values...)
+		default:
+			return f, fmt.Errorf("unknown filter key %q", key)
+		}
+	}
+	return f, nil
+}
+
+func splitFilterValues(s string) []string {
+	out := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// checkUserRefs accepts numeric ids and "me" (plus "none" for assignees)
+func checkUserRefs(values []string, allowNone bool) error {
+	for _, v := range values {
+		if v == "me" || (allowNone && v == "none") {
+			continue
+		}
+		if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+			return fmt.Errorf("invalid user reference %q", v)
+		}
+	}
+	return nil
+}
+
+// resolveUserRefs turns "me" into the caller id and drops "none"
+func resolveUserRefs(values []string, userID uint) []uint {
+	ids := []uint{}
+	for _, v := range values {
+		if v == "me" {
+			ids = append(ids, userID)
+			continue
+		}
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// applyTaskFilter adds the WHERE clauses of f to a query on the tasks table.
+// userID is the caller, used to resolve "me".
+func applyTaskFilter(q *gorm.DB, f taskFilter, userID uint) *gorm.DB {
+	if len(f.Statuses) > 0 {
+		q = q.Where("tasks.status IN ?", f.Statuses)
+	}
+	if len(f.Priorities) > 0 {
+		q = q.Where("tasks.priority IN ?", f.Priorities)
+	}
+	if len(f.Assignees) > 0 {
+		ids := resolveUserRefs(f.Assignees, userID)
+		wantNone := false
+		for _, v := range f.Assignees {
+			if v == "none" {
+				wantNone = true
+			}
+		}
+		assigned := "tasks.id IN (SELECT task_id FROM task_assignees WHERE user_id IN ? AND deleted_at IS NULL)"
+		unassigned := "tasks.id NOT IN (SELECT task_id FROM task_assignees WHERE deleted_at IS NULL)"
+		switch {
+		case len(ids) > 0 && wantNone:
+			q = q.Where("("+assigned+" OR "+unassigned+")", ids)
+		case wantNone:
+			q = q.Where(unassigned)
+		default:
+			q = q.Where(assigned, ids)
+		}
+	}
+	if len(f.Creators) > 0 {
+		q = q.Where("tasks.creator_id IN ?", resolveUserRefs(f.Creators, userID))
+	}
+	if len(f.Sprints) > 0 {
+		clauses := []string{}
+		args := []interface{}{}
+		ids := []uint{}
+		for _, v := range f.Sprints {
+			switch v {
+			case "none":
+				clauses = append(clauses, "tasks.sprint_id IS NULL")
+			case "active":
+				clauses = append(clauses, "tasks.sprint_id IN (SELECT id FROM sprints WHERE state = ? AND deleted_at IS NULL)")
+				args = append(args, models.SprintActive)
+			default:
+				if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+					ids = append(ids, uint(id))
+				}
+			}
+		}
+		if len(ids) > 0 {
+			clauses = append(clauses, "tasks.sprint_id IN ?")
+			args = append(args, ids)
+		}
+		q = q.Where("("+strings.Join(clauses, " OR ")+")", args...)
+	}
+	if f.Overdue != nil {
+		now := time.Now()
+		if *f.Overdue {
+			q = q.Where("tasks.due_date < ? AND tasks.status <> ?", now, models.TaskStatusDone)
+		} else {
+			q = q.Where("(tasks.due_date IS NULL OR tasks.due_date >= ? OR tasks.status = ?)", now, models.TaskStatusDone)
+		}
+	}
+	if f.DueBefore != nil {
+		q = q.Where("tasks.due_date < ?", *f.DueBefore)
+	}
+	if f.DueAfter != nil {
+		q = q.Where("tasks.due_date >= ?", *f.DueAfter)
+	}
+	for _, word := range f.Text {
+		like := "%" + word + "%"
+		q = q.Where("(tasks.title LIKE ? OR tasks.description LIKE ?)", like, like)
+	}
+	return q
+}
+
+// taskSortExpressions lists the allowed sort keys and their SQL expression
+var taskSortExpressions = map[string]string{
+	"created_at": "tasks.created_at",
+	"updated_at": "tasks.updated_at",
+	"due_date":   "tasks.due_date",
+	"title":      "tasks.title",
+	"status":     "CASE tasks.status WHEN 'TODO' THEN 0 WHEN 'DOING' THEN 1 WHEN 'DONE' THEN 2 ELSE 3 END",
+	"priority":   "CASE tasks.priority WHEN 'HIGH' THEN 0 WHEN 'MEDIUM' THEN 1 WHEN 'LOW' THEN 2 ELSE 3 END",
+}
+
+// applyTaskSort orders the query, falling back to the task id for stability
+func applyTaskSort(q *gorm.DB, sortBy string, desc bool) *gorm.DB {
+	if expr, ok := taskSortExpressions[sortBy]; ok {
+		if desc {
+			expr += " DESC"
+		}
+		q = q.Order(expr)
+	}
+	return q.Order("tasks.id")
+}
+
+// taskGroupKeys lists the allowed group_by values
+var taskGroupKeys = map[string]bool{
+	"status":   true,
+	"priority": true,
+	"assignee": true,
+	"due_date": true,
+	"project":  true,
+}
+
+type taskGroup struct {
+	Key   string        `json:"key"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+// groupTasks splits tasks by groupBy, keeping the order of first appearance.
+// A task with several assignees appears in each of their groups.
+func groupTasks(tasks []models.Task, groupBy string) []taskGroup {
+	groups := []taskGroup{}
+	index := map[string]int{}
+	add := func(key string, t models.Task) {
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, taskGroup{Key: key})
+		}
+		groups[i].Tasks = append(groups[i].Tasks, t)
+	}
+
+	for _, t := range tasks {
+		switch groupBy {
+		case "status":
+			add(t.Status, t)
+		case "priority":
+			add(t.Priority, t)
+		case "project":
+			add(strconv.FormatUint(uint64(t.ProjectID), 10), t)
+		case "due_date":
+			if t.DueDate == nil {
+				add("none", t)
+			} else {
+				add(t.DueDate.Format(filterDateLayout), t)
+			}
+		case "assignee":
+			if len(t.Assignees) == 0 {
+				add("none", t)
+			}
+			for _, a := range t.Assignees {
+				add(strconv.FormatUint(uint64(a.UserID), 10), t)
+			}
+		}
+	}
+	return groups
+}
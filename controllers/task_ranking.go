@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// Board ranks are base-36 strings compared lexicographically, read as the
+// fraction 0.d1d2d3... A task moved between two neighbours gets a rank strictly
+// between theirs, so a move only writes one row. Ranks grow by one digit each
+// time the same gap is split; rebalanceColumn spreads a column out again.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// maxRankLength triggers an immediate rebalance after a move,
+// rebalanceRankLength is the threshold of the periodic job
+const (
+	maxRankLength       = 24
+	rebalanceRankLength = 12
+)
+
+func rankDigit(b byte) int {
+	if i := strings.IndexByte(rankDigits, b); i >= 0 {
+		return i
+	}
+	return 0
+}
+
+// rankBetween returns a rank strictly between prev and next.
+// An empty prev means "start of column", an empty next "end of column".
+// prev must sort before next, otherwise next is ignored. It returns ""
+// when no rank fits (next is prev followed by a single '0'): the
+// column must then be rebalanced.
+func rankBetween(prev, next string) string {
+	if next != "" && prev >= next {
+		next = ""
+	}
+	out := []byte{}
+	bounded := next != ""
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(prev) {
+			lo = rankDigit(prev[i])
+		}
+		hi := len(rankDigits)
+		if bounded {
+			if i >= len(next) {
+				// out == next, prolongé de '0' depuis prev : next sans son
+				// dernier '0' convient s'il reste plus long que prev
+				if len(next)-1 > len(prev) {
+					return next[:len(next)-1]
+				}
+				return ""
+			}
+			hi = rankDigit(next[i])
+		}
+
+		if lo == hi {
+			out = append(out, rankDigits[lo])
+			continue
+		}
+		if mid := (lo + hi) / 2; mid > lo {
+			return string(append(out, rankDigits[mid]))
+		}
+		// adjacent digits: keep prev's digit, everything after it is below next
+		out = append(out, rankDigits[lo])
+		bounded = false
+	}
+}
+
+// evenRanks returns n ranks spread over the whole rank space. Trailing '0'
+// digits are dropped so that a rank always fits before another one.
+func evenRanks(n int) []string {
+	const width = 4
+	space := 1
+	for i := 0; i < width; i++ {
+		space *= len(rankDigits)
+	}
+	step := space / (n + 1)
+	ranks := make([]string, n)
+	for i := range ranks {
+		v := step * (i + 1)
+		b := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			b[j] = rankDigits[v%len(rankDigits)]
+			v /= len(rankDigits)
+		}
+		ranks[i] = strings.TrimRight(string(b), "0")
+	}
+	return ranks
+}
+
+// lastRankInColumn returns the highest rank of a status column ("" if empty)
+func lastRankInColumn(db *gorm.DB, projectID uint, status string) (string, error) {
+	var ranks []string
+	err := db.Model(&models.Task{}).
+		Where("project_id = ? AND status = ?", projectID, status).
+		Order("board_rank DESC").Limit(1).
+		Pluck("board_rank", &ranks).Error
+	if err != nil || len(ranks) == 0 {
+		return "", err
+	}
+	return ranks[0], nil
+}
+
+// rankAtEndOfColumn gives the rank for a task appended to a status column
+func rankAtEndOfColumn(db *gorm.DB, projectID uint, status string) (string, error) {
+	last, err := lastRankInColumn(db, projectID, status)
+	if err != nil {
+		return "", err
+	}
+	return rankBetween(last, ""), nil
+}
+
+// rebalanceColumn rewrites the ranks of a status column with evenly spaced
+// values, keeping the current order (tasks without rank go last).
+func rebalanceColumn(db *gorm.DB, projectID uint, status string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var tasks []models.Task
+		if err := tx.Select("id", "board_rank").
+			Where("project_id = ? AND status = ?", projectID, status).
+			Order("board_rank = '', board_rank, id").
+			Find(&tasks).Error; err != nil {
+			return err
+		}
+		ranks := evenRanks(len(tasks))
+		for i, t := range tasks {
+			if err := tx.Model(&models.Task{}).Where("id = ?", t.ID).
+				UpdateColumn("board_rank", ranks[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RebalanceRanks rebalances every column that has long or missing ranks
+func RebalanceRanks() error {
+	type column struct {
+		ProjectID uint
+		Status    string
+	}
+	var cols []column
+	if err := initializers.DB.Model(&models.Task{}).
+		Distinct("project_id", "status").
+		Where("LENGTH(board_rank) > ? OR board_rank = '' OR board_rank IS NULL", rebalanceRankLength).
+		Find(&cols).Error; err != nil {
+		return err
+	}
+	for _, col := range cols {
+		if err := rebalanceColumn(initializers.DB, col.ProjectID, col.Status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartRankRebalancer runs RebalanceRanks in the background every interval
+func StartRankRebalancer(interval time.Duration) {
+	go func() {
+		for {
+			if err := RebalanceRanks(); err != nil {
+				log.Printf("rank rebalance failed: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		prev, next string
+		want       string // "" = no rank fits
+	}{
+		{"", "", "i"},
+		{"i", "", "r"},
+		{"", "i", "9"},
+		{"9", "r", "i"},
+		{"i", "i000", "i00"},
+		{"i", "i001", "i000i"},
+		{"a5", "a50", ""},
+		{"", "0", ""},
+		{"", "00", "0"},
+		{"a", "b", "ai"},
+		{"az", "b", "azi"},
+		{"z", "", "zi"},
+		{"zz", "", "zzi"},
+		{"a", "a1", "a0i"},
+		{"r", "i", "v"}, // next avant prev : ignoré
+	}
+	for _, tt := range tests {
+		got := rankBetween(tt.prev, tt.next)
+		if got != tt.want {
+			t.Errorf("rankBetween(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+		}
+	}
+}
+
+// randomRank returns a rank without trailing '0', as stored ranks are
+func randomRank(r *rand.Rand) string {
+	b := make([]byte, 1+r.Intn(6))
+	for i := range b {
+		b[i] = rankDigits[r.Intn(len(rankDigits))]
+	}
+	return strings.TrimRight(string(b), "0")
+}
+
+func TestRankBetweenSortsStrictlyBetween(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		prev, next := randomRank(r), randomRank(r)
+		if r.Intn(10) == 0 {
+			prev = ""
+		}
+		if r.Intn(10) == 0 {
+			next = ""
+		}
+		if next != "" && prev >= next {
+			prev, next = next, prev
+		}
+		if prev == next {
+			continue
+		}
+		got := rankBetween(prev, next)
+		if got == "" {
+			t.Fatalf("rankBetween(%q, %q) found no rank", prev, next)
+		}
+		if got <= prev || (next != "" && got >= next) {
+			t.Fatalf("rankBetween(%q, %q) = %q, not strictly between", prev, next, got)
+		}
+		if strings.HasSuffix(got, "0") {
+			t.Fatalf("rankBetween(%q, %q) = %q ends with '0'", prev, next, got)
+		}
+	}
+}
+
+func TestRankBetweenRepeatedSplits(t *testing.T) {
+	// toujours le même espace coupé en deux, vers le haut puis vers le bas
+	prev, next := "9", "i"
+	for i := 0; i < 200; i++ {
+		mid := rankBetween(prev, next)
+		if mid <= prev || mid >= next {
+			t.Fatalf("split %d: %q not between %q and %q", i, mid, prev, next)
+		}
+		if i%2 == 0 {
+			prev = mid
+		} else {
+			next = mid
+		}
+	}
+}
+
+func TestEvenRanks(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 10, 1000} {
+		ranks := evenRanks(n)
+		for i, rk := range ranks {
+			if rk == "" || strings.HasSuffix(rk, "0") {
+				t.Fatalf("evenRanks(%d)[%d] = %q", n, i, rk)
+			}
+			if i > 0 && ranks[i-1] >= rk {
+				t.Fatalf("evenRanks(%d) not sorted: %q >= %q", n, ranks[i-1], rk)
+			}
+		}
+	}
+	if got := evenRanks(3); strings.Join(got, ",") != "9,i,r" {
+		t.Errorf("evenRanks(3) = %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
 )
 
 //
@@ -22,6 +24,14 @@ type createTaskPayload struct {
 	Description string     `json:"description"`
 	DueDate     *time.Time `json:"due_date"`
 	Priority    string     `json:"priority"`
+
+	// récurrence optionnelle, ex. "FREQ=WEEKLY;BYDAY=MO"
+	Recurrence     string `json:"recurrence"`
+	RecurrenceMode string `json:"recurrence_mode"`
+
+	OriginalEstimate *int   `json:"original_estimate_minutes"` // minutes
+	SprintID         *uint  `json:"sprint_id"`
+	Estimate         string `json:"estimate"` // dans l'échelle du projet
 }
 
 // CreateTask : n’importe quel membre du projet peut créer une tâche
@@ -72,10 +82,67 @@ func CreateTask(c *gin.Context) {
 		task.Priority = models.TaskPriorityMedium
 	}
 
+	if body.Estimate != "" {
+		scale, err := projectEstimateScale(projectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		label, points, err := parseEstimate(scale, body.Estimate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.Estimate, task.StoryPoints = label, points
+	}
+
+	if body.SprintID != nil && *body.SprintID != 0 {
+		if err := validateSprintTask(projectID, *body.SprintID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.SprintID = body.SprintID
+	}
+
+	if body.OriginalEstimate != nil {
+		if *body.OriginalEstimate < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "original_estimate_minutes must be >= 0"})
+			return
+		}
+		remaining := *body.OriginalEstimate
+		task.OriginalEstimate = body.OriginalEstimate
+		task.RemainingEstimate = &remaining
+	}
+
+	if body.Recurrence != "" {
+		cols, err := recurrenceColumns(body.Recurrence, body.RecurrenceMode, body.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		task.Recurrence = cols["recurrence"].(string)
+		task.RecurrenceMode = cols["recurrence_mode"].(string)
+		task.RecurrenceStart = body.DueDate
+		task.RecurrenceIndex = 1
+	}
+
+	// Nouvelle tâche : en bas de la colonne TODO
+	task.Status = models.TaskStatusTodo
+	rank, err := rankAtEndOfColumn(initializers.DB, projectID, task.Status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	task.Rank = rank
+
 	if err := initializers.DB.Create(&task).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create task"})
 		return
 	}
+	recordTaskActivity(initializers.DB, task, userID, models.ActivityCreated, "", "", task.Title)
+	watchTask(task.ID, userID)
+	emitProjectEvent(projectID, userID, realtime.TaskCreated, gin.H{"task": task})
+	notifyMentions(task, userID, "", task.Title+"\n"+task.Description)
 
 	c.JSON(http.StatusCreated, gin.H{"task": task})
 }
@@ -109,10 +176,28 @@ func GetProjectTasks(c *gin.Context) {
 		return
 	}
 
+	// Filtre optionnel : ?filter=status:TODO priority:HIGH ... &sort=due_date&desc=true
+	filter, err := parseTaskFilter(c.Query("filter"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sortBy := c.Query("sort")
+	if _, ok := taskSortExpressions[sortBy]; sortBy != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort"})
+		return
+	}
+
 	// Chargement des tâches + assignees (avec info du User)
 	var tasks []models.Task
-	if err := initializers.DB.
-		Where("project_id = ?", projectID).
+	q := initializers.DB.Where("tasks.project_id = ?", projectID)
+	q = applyTaskFilter(q, filter, userID)
+	if sortBy == "" {
+		// ordre du board : colonne par colonne, puis rang dans la colonne
+		q = q.Order(taskSortExpressions["status"]).Order("tasks.board_rank")
+	}
+	q = applyTaskSort(q, sortBy, c.Query("desc") == "true")
+	if err := q.
 		Preload("Assignees.User"). // <-- important pour le front : retourne les users assignés
 		Find(&tasks).Error; err != nil {
 
@@ -134,9 +219,31 @@ type updateTaskPayload struct {
 	Status      *string    `json:"status"`
 	Priority    *string    `json:"priority"`
 	DueDate     *time.Time `json:"due_date"`
+	Version     *uint      `json:"version"` // si pas de header If-Match
+
+	Recurrence     *string `json:"recurrence"` // "" = plus de récurrence
+	RecurrenceMode *string `json:"recurrence_mode"`
+
+	OriginalEstimate  *int `json:"original_estimate_minutes"`
+	RemainingEstimate *int `json:"remaining_estimate_minutes"`
+
+	SprintID *uint   `json:"sprint_id"` // 0 = retour au backlog
+	Estimate *string `json:"estimate"`  // "" = pas d'estimation
 }
 
-// UpdateTask : seul le créateur OU le propriétaire du projet peut modifier
+// respondTaskConflict : 412 avec l'état actuel de la tâche pour que le client fusionne
+func respondTaskConflict(c *gin.Context, taskID uint) {
+	var current models.Task
+	if err := initializers.DB.Preload("Assignees.User").First(&current, taskID).Error; err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version conflict"})
+		return
+	}
+	setETag(c, current.Version)
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version conflict", "task": current})
+}
+
+// UpdateTask : seul le créateur OU le propriétaire du projet peut modifier.
+// La version attendue (If-Match ou champ version) est obligatoire.
 func UpdateTask(c *gin.Context) {
 
 	tidStr := c.Param("taskId")
@@ -181,6 +288,16 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
+	// Verrou optimiste : le client doit modifier la version qu'il a lue
+	expected, ok := requireVersion(c, body.Version)
+	if !ok {
+		return
+	}
+	if task.Version != expected {
+		respondTaskConflict(c, taskID)
+		return
+	}
+
 	// Mise à jour partielle
 	updated := map[string]interface{}{}
 	if body.Title != nil {
@@ -189,14 +306,108 @@ func UpdateTask(c *gin.Context) {
 	if body.Description != nil {
 		updated["description"] = *body.Description
 	}
+	var wipWarnings []wipViolation
 	if body.Status != nil {
 		updated["status"] = *body.Status
+		// changement de colonne : la tâche passe en bas de la nouvelle colonne
+		if *body.Status != task.Status {
+			var allowed bool
+			if wipWarnings, allowed = enforceWipLimits(c, task, *body.Status); !allowed {
+				return
+			}
+			rank, err := rankAtEndOfColumn(initializers.DB, task.ProjectID, *body.Status)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+				return
+			}
+			updated["board_rank"] = rank
+		}
 	}
 	if body.Priority != nil {
 		updated["priority"] = *body.Priority
 	}
 	if body.DueDate != nil {
 		updated["due_date"] = body.DueDate
+		// nouvelle échéance : rappel et retard repartent de zéro
+		if task.DueDate == nil || !task.DueDate.Equal(*body.DueDate) {
+			updated["reminder_sent_at"] = nil
+			updated["overdue_at"] = nil
+			updated["escalated_at"] = nil
+		}
+	}
+
+	if body.Estimate != nil {
+		scale, err := projectEstimateScale(task.ProjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		label, points, err := parseEstimate(scale, *body.Estimate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated["estimate"] = label
+		updated["story_points"] = points
+	}
+	if body.SprintID != nil {
+		if err := validateSprintTask(task.ProjectID, *body.SprintID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if *body.SprintID == 0 {
+			updated["sprint_id"] = nil
+		} else {
+			updated["sprint_id"] = *body.SprintID
+		}
+	}
+	if body.OriginalEstimate != nil {
+		if *body.OriginalEstimate < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "original_estimate_minutes must be >= 0"})
+			return
+		}
+		updated["original_estimate"] = *body.OriginalEstimate
+		// première estimation : le reste démarre à l'estimation
+		if task.RemainingEstimate == nil && body.RemainingEstimate == nil {
+			updated["remaining_estimate"] = *body.OriginalEstimate
+		}
+	}
+	if body.RemainingEstimate != nil {
+		if *body.RemainingEstimate < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "remaining_estimate_minutes must be >= 0"})
+			return
+		}
+		updated["remaining_estimate"] = *body.RemainingEstimate
+	}
+	if body.Recurrence != nil {
+		// nouvelle règle : la série repart de l'échéance (nouvelle ou actuelle)
+		due := task.DueDate
+		if body.DueDate != nil {
+			due = body.DueDate
+		}
+		mode := task.RecurrenceMode
+		if body.RecurrenceMode != nil {
+			mode = *body.RecurrenceMode
+		}
+		cols, err := recurrenceColumns(*body.Recurrence, mode, due)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for k, v := range cols {
+			updated[k] = v
+		}
+	} else if body.RecurrenceMode != nil {
+		if task.Recurrence == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "task is not recurring"})
+			return
+		}
+		mode, err := checkRecurrenceMode(*body.RecurrenceMode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated["recurrence_mode"] = mode
 	}
 
 	if len(updated) == 0 {
@@ -204,11 +415,21 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
-	// DB update
-	if err := initializers.DB.Model(&task).Updates(updated).Error; err != nil {
+	// DB update, seulement si personne n'a modifié la tâche entre-temps
+	before := task
+	updated["version"] = gorm.Expr("version + 1")
+	res := initializers.DB.Model(&models.Task{}).
+		Where("id = ? AND version = ?", taskID, expected).
+		Updates(updated)
+	if res.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update task"})
 		return
 	}
+	if res.RowsAffected == 0 {
+		respondTaskConflict(c, taskID)
+		return
+	}
+	recordTaskChanges(initializers.DB, before, updated, userID)
 
 	// Reload avec preload pour envoyer les assignees
 	if err := initializers.DB.
@@ -222,7 +443,224 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"task": task})
+	emitProjectEvent(task.ProjectID, userID, realtime.TaskUpdated, gin.H{"task": task})
+	if task.Status != before.Status {
+		emitProjectEvent(task.ProjectID, userID, realtime.TaskStatusChanged, gin.H{"task": task, "from": before.Status, "to": task.Status})
+		notifyStatusChange(task, userID, before.Status, task.Status)
+		afterTaskDone(task)
+	}
+	changed := []string{}
+	for _, col := range []string{"title", "description", "priority", "due_date"} {
+		if _, ok := updated[col]; ok && formatActivityValue(taskColumnValue(before, col)) != formatActivityValue(taskColumnValue(task, col)) {
+			changed = append(changed, col)
+		}
+	}
+	notifyTaskUpdated(task, userID, changed)
+	notifyMentions(task, userID, before.Title+"\n"+before.Description, task.Title+"\n"+task.Description)
+
+	setETag(c, task.Version)
+	resp := gin.H{"task": task}
+	if len(wipWarnings) > 0 {
+		resp["wip_warnings"] = wipWarnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+//
+// --------------------------- MOVE (KANBAN) ---------------------------
+//
+
+// movePayload : colonne cible + voisins dans cette colonne (optionnels)
+type movePayload struct {
+	Status string `json:"status" binding:"required"`
+	PrevID *uint  `json:"prev_id"` // tâche juste au-dessus
+	NextID *uint  `json:"next_id"` // tâche juste en dessous
+}
+
+var boardStatuses = map[string]bool{
+	models.TaskStatusTodo:  true,
+	models.TaskStatusDoing: true,
+	models.TaskStatusDone:  true,
+}
+
+// loadNeighbourRank : rang d'un voisin, qui doit être dans la même colonne
+func loadNeighbourRank(id *uint, task models.Task, status string) (string, bool, error) {
+	if id == nil {
+		return "", true, nil
+	}
+	if *id == task.ID {
+		return "", false, nil
+	}
+	var n models.Task
+	if err := initializers.DB.First(&n, *id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if n.ProjectID != task.ProjectID || n.Status != status {
+		return "", false, nil
+	}
+	return n.Rank, true, nil
+}
+
+// neighboursAdjacent checks that no other task of the column sorts between
+// the given neighbours (a single neighbour must be the first or last task)
+func neighboursAdjacent(task models.Task, status string, hasPrev bool, prevRank string, hasNext bool, nextRank string) (bool, error) {
+	q := initializers.DB.Model(&models.Task{}).
+		Where("project_id = ? AND status = ? AND id <> ? AND board_rank <> ''", task.ProjectID, status, task.ID)
+	if hasPrev {
+		q = q.Where("board_rank > ?", prevRank)
+	}
+	if hasNext {
+		q = q.Where("board_rank < ?", nextRank)
+	}
+	var n int64
+	err := q.Count(&n).Error
+	return n == 0, err
+}
+
+// MoveTask : déplace une tâche dans le board (colonne + position).
+// Réordonner est permis à tout membre ; changer de statut suit la règle de UpdateTask.
+func MoveTask(c *gin.Context) {
+
+	tidStr := c.Param("taskId")
+	tid64, err := strconv.ParseUint(tidStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+	taskID := uint(tid64)
+
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var task models.Task
+	if err := initializers.DB.First(&task, taskID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+
+	isMember, err := IsProjectMember(task.ProjectID, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+		return
+	}
+
+	var body movePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !boardStatuses[body.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+
+	if body.Status != task.Status && task.CreatorID != userID {
+		isOwner, err := IsProjectOwner(task.ProjectID, userID)
+		if err != nil || !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only creator or owner can change task status"})
+			return
+		}
+	}
+
+	// Limites WIP : seulement si la tâche change de colonne
+	var wipWarnings []wipViolation
+	if body.Status != task.Status {
+		var allowed bool
+		if wipWarnings, allowed = enforceWipLimits(c, task, body.Status); !allowed {
+			return
+		}
+	}
+
+	// Rangs des voisins ; si l'espace est épuisé ou incohérent, on rééquilibre la colonne
+	var prevRank, nextRank, rank string
+	for attempt := 0; attempt < 2; attempt++ {
+		var okPrev, okNext bool
+		prevRank, okPrev, err = loadNeighbourRank(body.PrevID, task, body.Status)
+		if err == nil {
+			nextRank, okNext, err = loadNeighbourRank(body.NextID, task, body.Status)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		if !okPrev || !okNext {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "neighbour tasks must be other tasks of the target column"})
+			return
+		}
+		if body.PrevID == nil && body.NextID == nil {
+			prevRank, err = lastRankInColumn(initializers.DB, task.ProjectID, body.Status)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+				return
+			}
+		}
+
+		usable := (body.PrevID == nil || prevRank != "") && (body.NextID == nil || nextRank != "")
+		if usable && (nextRank == "" || prevRank < nextRank) {
+			if body.PrevID != nil || body.NextID != nil {
+				adjacent, err := neighboursAdjacent(task, body.Status, body.PrevID != nil, prevRank, body.NextID != nil, nextRank)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+					return
+				}
+				if !adjacent {
+					c.JSON(http.StatusConflict, gin.H{"error": "neighbour tasks are not adjacent"})
+					return
+				}
+			}
+			if rank = rankBetween(prevRank, nextRank); rank != "" {
+				break
+			}
+		}
+		if attempt == 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "neighbour tasks are not adjacent"})
+			return
+		}
+		if err := rebalanceColumn(initializers.DB, task.ProjectID, body.Status); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not rebalance column"})
+			return
+		}
+	}
+
+	before := task
+	moved := map[string]interface{}{
+		"status":     body.Status,
+		"board_rank": rank,
+		"version":    gorm.Expr("version + 1"),
+	}
+	if err := initializers.DB.Model(&models.Task{}).Where("id = ?", taskID).Updates(moved).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not move task"})
+		return
+	}
+	recordTaskChanges(initializers.DB, before, moved, userID)
+
+	if len(rank) > maxRankLength {
+		if err := rebalanceColumn(initializers.DB, task.ProjectID, body.Status); err != nil {
+			log.Printf("could not rebalance column %s of project %d: %v", body.Status, task.ProjectID, err)
+		}
+	}
+	initializers.DB.First(&task, taskID)
+
+	if task.Status != before.Status {
+		emitProjectEvent(task.ProjectID, userID, realtime.TaskStatusChanged, gin.H{"task": task, "from": before.Status, "to": task.Status})
+		notifyStatusChange(task, userID, before.Status, task.Status)
+		afterTaskDone(task)
+	} else {
+		emitProjectEvent(task.ProjectID, userID, realtime.TaskMoved, gin.H{"task": task})
+	}
+
+	setETag(c, task.Version)
+	resp := gin.H{"task": task}
+	if len(wipWarnings) > 0 {
+		resp["wip_warnings"] = wipWarnings
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 //
@@ -280,21 +718,54 @@ func AssignTask(c *gin.Context) {
 		return
 	}
 
+	// limite WIP de l'assigné dans la colonne de la tâche (s'il ne l'a pas déjà)
+	var already int64
+	if err := initializers.DB.Model(&models.TaskAssignee{}).Where("task_id = ? AND user_id = ?", taskID, body.UserID).Count(&already).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	var wipWarnings []wipViolation
+	if already == 0 {
+		var allowed bool
+		if wipWarnings, allowed = enforceAddedWipLimits(c, task.ProjectID, task.Status, 0, oneTaskEach([]uint{body.UserID})); !allowed {
+			return
+		}
+	}
+
 	// Création du lien TaskAssignee
 	ass := models.TaskAssignee{
 		TaskID: taskID,
 		UserID: body.UserID,
 	}
 
-	if err := initializers.DB.
-		Where("task_id = ? AND user_id = ?", taskID, body.UserID).
-		FirstOrCreate(&ass).Error; err != nil {
-
+	// le lien et la version de la tâche changent ensemble
+	created := false
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("task_id = ? AND user_id = ?", taskID, body.UserID).FirstOrCreate(&ass)
+		if res.Error != nil {
+			return res.Error
+		}
+		if created = res.RowsAffected > 0; created {
+			return bumpTaskVersion(tx, taskID)
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not assign"})
 		return
 	}
+	if created {
+		recordTaskActivity(initializers.DB, task, userID, models.ActivityAssigned, "assignee", "", formatActivityValue(body.UserID))
+		watchTask(task.ID, body.UserID)
+		emitProjectEvent(task.ProjectID, userID, realtime.TaskAssigned, gin.H{"task_id": task.ID, "user_id": body.UserID})
+		notifyAssigned(task, body.UserID, userID)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "assigned"})
+	resp := gin.H{"message": "assigned"}
+	if len(wipWarnings) > 0 {
+		resp["wip_warnings"] = wipWarnings
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // UnassignTask : enlève un user d’une tâche
@@ -336,13 +807,25 @@ func UnassignTask(c *gin.Context) {
 	}
 
 	// Suppression de l'assignee
-	if err := initializers.DB.
-		Where("task_id = ? AND user_id = ?", taskID, body.UserID).
-		Delete(&models.TaskAssignee{}).Error; err != nil {
-
+	removed := false
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("task_id = ? AND user_id = ?", taskID, body.UserID).Delete(&models.TaskAssignee{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if removed = res.RowsAffected > 0; removed {
+			return bumpTaskVersion(tx, taskID)
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not unassign"})
 		return
 	}
+	if removed {
+		recordTaskActivity(initializers.DB, task, userID, models.ActivityUnassigned, "assignee", formatActivityValue(body.UserID), "")
+		emitProjectEvent(task.ProjectID, userID, realtime.TaskUnassigned, gin.H{"task_id": task.ID, "user_id": body.UserID})
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "unassigned"})
 }
@@ -351,7 +834,8 @@ func UnassignTask(c *gin.Context) {
 // --------------------------- DELETE TASK ---------------------------
 //
 
-// DeleteTask : seul le créateur ou l’owner du projet peut supprimer
+// DeleteTask : seul le créateur ou l’owner du projet peut supprimer.
+// La version attendue vient de If-Match ou de ?version=.
 func DeleteTask(c *gin.Context) {
 
 	tidStr := c.Param("taskId")
@@ -385,11 +869,27 @@ func DeleteTask(c *gin.Context) {
 		}
 	}
 
+	expected, ok := requireVersion(c, queryVersion(c))
+	if !ok {
+		return
+	}
+	if task.Version != expected {
+		respondTaskConflict(c, taskID)
+		return
+	}
+
 	// Suppression
-	if err := initializers.DB.Delete(&models.Task{}, taskID).Error; err != nil {
+	res := initializers.DB.Where("version = ?", expected).Delete(&models.Task{}, taskID)
+	if res.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete task"})
 		return
 	}
+	if res.RowsAffected == 0 {
+		respondTaskConflict(c, taskID)
+		return
+	}
+	recordTaskActivity(initializers.DB, task, userID, models.ActivityDeleted, "", task.Title, "")
+	emitProjectEvent(task.ProjectID, userID, realtime.TaskDeleted, gin.H{"task_id": task.ID})
 
 	c.JSON(http.StatusOK, gin.H{"message": "task deleted"})
 }
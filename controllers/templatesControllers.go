@@ -0,0 +1,480 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+const minutesPerDay = 24 * 60
+
+// dueOffset is the due date as days (calendar days, so DST does not shift
+// it) and minutes of the day from anchor, a local midnight
+func dueOffset(anchor, due time.Time) int {
+	day := truncateDay(due)
+	days := int(math.Floor(day.Sub(anchor).Hours()/24 + 0.5))
+	return days*minutesPerDay + int(due.In(time.Local).Sub(day).Minutes())
+}
+
+// applyDueOffset is the reverse of dueOffset from a new start date
+func applyDueOffset(start time.Time, offset int) time.Time {
+	days := int(math.Floor(float64(offset) / minutesPerDay))
+	minutes := offset - days*minutesPerDay
+	return start.AddDate(0, 0, days).Add(time.Duration(minutes) * time.Minute)
+}
+
+// parseStartDate reads a YYYY-MM-DD date, def when empty
+func parseStartDate(raw string, def time.Time) (time.Time, error) {
+	if raw == "" {
+		return truncateDay(def), nil
+	}
+	d, err := time.ParseInLocation(filterDateLayout, raw, time.Local)
+	if err != nil {
+		return d, errors.New("dates must be YYYY-MM-DD")
+	}
+	return d, nil
+}
+
+// snapshotProject builds an unsaved template of a project. Due dates are
+// made relative to anchor; members are kept only if withMembers.
+func snapshotProject(projectID uint, anchor time.Time, withMembers bool) (models.ProjectTemplate, error) {
+	var tpl models.ProjectTemplate
+	var project models.Project
+	if err := initializers.DB.First(&project, projectID).Error; err != nil {
+		return tpl, err
+	}
+	tpl = models.ProjectTemplate{
+		SourceProjectID:    &project.ID,
+		ProjectDescription: project.Description,
+		WipMode:            project.WipMode,
+		EstimateScale:      project.EstimateScale,
+	}
+
+	var tasks []models.Task
+	if err := initializers.DB.Where("project_id = ?", projectID).
+		Order(taskSortExpressions["status"]).Order("tasks.board_rank").Order("tasks.id").
+		Find(&tasks).Error; err != nil {
+		return tpl, err
+	}
+	for i, t := range tasks {
+		tt := models.TemplateTask{
+			Position:         i,
+			Title:            t.Title,
+			Description:      t.Description,
+			Status:           t.Status,
+			Priority:         t.Priority,
+			Estimate:         t.Estimate,
+			OriginalEstimate: t.OriginalEstimate,
+		}
+		if t.DueDate != nil {
+			off := dueOffset(anchor, *t.DueDate)
+			tt.DueOffsetMinutes = &off
+			tt.Recurrence, tt.RecurrenceMode = t.Recurrence, t.RecurrenceMode
+		}
+		tpl.Tasks = append(tpl.Tasks, tt)
+	}
+
+	var limits []models.WipLimit
+	if err := initializers.DB.Where("project_id = ?", projectID).Order("status, assignee_id").Find(&limits).Error; err != nil {
+		return tpl, err
+	}
+	for _, l := range limits {
+		if l.AssigneeID != nil && !withMembers {
+			continue
+		}
+		tpl.WipLimits = append(tpl.WipLimits, models.TemplateWipLimit{Status: l.Status, AssigneeID: l.AssigneeID, MaxTasks: l.MaxTasks})
+	}
+
+	if withMembers {
+		var members []models.ProjectMember
+		if err := initializers.DB.Where("project_id = ?", projectID).Order("id").Find(&members).Error; err != nil {
+			return tpl, err
+		}
+		for _, m := range members {
+			tpl.Members = append(tpl.Members, models.TemplateMember{UserID: m.UserID, Role: models.RoleMember})
+		}
+	}
+	return tpl, nil
+}
+
+// instantiateOptions : projet à créer à partir d'un modèle
+type instantiateOptions struct {
+	Name        string
+	Description *string
+	Start       time.Time
+	Members     bool // membres du modèle, ajoutés en MEMBER
+	KeepStatus  bool // sinon toutes les tâches repartent en TODO
+}
+
+// instantiateTemplate creates a project from a template in tx, the caller
+// being its owner. Due dates are shifted from opts.Start.
+func instantiateTemplate(tx *gorm.DB, tpl models.ProjectTemplate, userID uint, opts instantiateOptions) (models.Project, error) {
+	project := models.Project{
+		Name:          opts.Name,
+		Description:   tpl.ProjectDescription,
+		OwnerID:       &userID,
+		WipMode:       tpl.WipMode,
+		EstimateScale: tpl.EstimateScale,
+	}
+	if opts.Description != nil {
+		project.Description = *opts.Description
+	}
+	if err := tx.Create(&project).Error; err != nil {
+		return project, err
+	}
+
+	members := []models.ProjectMember{{ProjectID: project.ID, UserID: userID, Role: models.RoleOwner}}
+	isMember := map[uint]bool{userID: true}
+	if opts.Members && len(tpl.Members) > 0 {
+		// les comptes supprimés depuis sont ignorés
+		ids := make([]uint, 0, len(tpl.Members))
+		for _, m := range tpl.Members {
+			ids = append(ids, m.UserID)
+		}
+		var existing []uint
+		if err := tx.Model(&models.User{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+			return project, err
+		}
+		for _, id := range existing {
+			if !isMember[id] {
+				isMember[id] = true
+				// le créateur reste le seul propriétaire
+				members = append(members, models.ProjectMember{ProjectID: project.ID, UserID: id, Role: models.RoleMember})
+			}
+		}
+	}
+	if err := tx.Create(&members).Error; err != nil {
+		return project, err
+	}
+
+	for _, l := range tpl.WipLimits {
+		if l.AssigneeID != nil && !isMember[*l.AssigneeID] {
+			continue
+		}
+		limit := models.WipLimit{ProjectID: project.ID, Status: l.Status, AssigneeID: l.AssigneeID, MaxTasks: l.MaxTasks}
+		if err := tx.Create(&limit).Error; err != nil {
+			return project, err
+		}
+	}
+
+	columns := map[string]bool{}
+	for _, tt := range tpl.Tasks {
+		task := models.Task{
+			ProjectID:        project.ID,
+			Title:            tt.Title,
+			Description:      tt.Description,
+			Status:           models.TaskStatusTodo,
+			Priority:         tt.Priority,
+			Estimate:         tt.Estimate,
+			OriginalEstimate: tt.OriginalEstimate,
+			CreatorID:        userID,
+		}
+		if opts.KeepStatus && boardStatuses[tt.Status] {
+			task.Status = tt.Status
+		}
+		if task.Priority == "" {
+			task.Priority = models.TaskPriorityMedium
+		}
+		if tt.Estimate != "" {
+			if label, points, err := parseEstimate(project.EstimateScale, tt.Estimate); err == nil {
+				task.Estimate, task.StoryPoints = label, points
+			} else {
+				task.Estimate = ""
+			}
+		}
+		if tt.OriginalEstimate != nil {
+			remaining := *tt.OriginalEstimate
+			task.RemainingEstimate = &remaining
+		}
+		if tt.DueOffsetMinutes != nil {
+			due := applyDueOffset(opts.Start, *tt.DueOffsetMinutes)
+			task.DueDate = &due
+			if tt.Recurrence != "" {
+				if cols, err := recurrenceColumns(tt.Recurrence, tt.RecurrenceMode, &due); err == nil {
+					task.Recurrence = cols["recurrence"].(string)
+					task.RecurrenceMode = cols["recurrence_mode"].(string)
+					task.RecurrenceStart = &due
+					task.RecurrenceIndex = 1
+				}
+			}
+		}
+
+		// rang vide : l'ordre du modèle est rétabli au rééquilibrage
+		if err := tx.Create(&task).Error; err != nil {
+			return project, err
+		}
+		columns[task.Status] = true
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.TaskWatcher{TaskID: task.ID, UserID: userID}).Error; err != nil {
+			return project, err
+		}
+		recordTaskActivity(tx, task, userID, models.ActivityCreated, "", "", task.Title)
+	}
+	for status := range columns {
+		if err := rebalanceColumn(tx, project.ID, status); err != nil {
+			return project, err
+		}
+	}
+	return project, nil
+}
+
+// instantiatePayload : création d'un projet depuis un modèle ou par clonage
+type instantiatePayload struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description"`
+	StartDate   string  `json:"start_date"`      // YYYY-MM-DD, défaut aujourd'hui
+	Members     bool    `json:"include_members"` // propriétaire du projet source seulement
+	KeepStatus  bool    `json:"keep_status"`
+
+	AnchorDate string `json:"anchor_date"` // clone : début du projet source, défaut sa création
+}
+
+func (p instantiatePayload) options() (instantiateOptions, error) {
+	start, err := parseStartDate(p.StartDate, time.Now())
+	opts := instantiateOptions{
+		Name:        p.Name,
+		Description: p.Description,
+		Start:       start,
+		Members:     p.Members,
+		KeepStatus:  p.KeepStatus,
+	}
+	return opts, err
+}
+
+// canCopyMembers : seul le propriétaire du projet source peut inscrire ses
+// membres ailleurs ; sans projet source, l'auteur du modèle
+func canCopyMembers(sourceProjectID *uint, authorID, userID uint) bool {
+	if sourceProjectID == nil {
+		return authorID == userID
+	}
+	isOwner, err := IsProjectOwner(*sourceProjectID, userID)
+	return err == nil && isOwner
+}
+
+// createFromTemplate runs instantiateTemplate in a transaction and responds
+func createFromTemplate(c *gin.Context, tpl models.ProjectTemplate, userID uint, opts instantiateOptions) {
+	var project models.Project
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		project, err = instantiateTemplate(tx, tpl, userID, opts)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create project"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"project": project, "tasks": len(tpl.Tasks)})
+}
+
+// CloneProject creates a copy of a project (members): settings, WIP limits,
+// tasks and, with include_members (owner only), the members as MEMBER.
+// Due dates keep their distance to anchor_date, moved to start_date.
+func CloneProject(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+
+	var body instantiatePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts, err := body.options()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.Members && !canCopyMembers(&projectID, 0, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owner can copy the members of a project"})
+		return
+	}
+	anchor, err := projectAnchor(projectID, body.AnchorDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tpl, err := snapshotProject(projectID, anchor, opts.Members)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	createFromTemplate(c, tpl, userID, opts)
+}
+
+// projectAnchor is the start of a project for relative due dates: the given
+// date, else the day the project was created
+func projectAnchor(projectID uint, raw string) (time.Time, error) {
+	if raw != "" {
+		return parseStartDate(raw, time.Time{})
+	}
+	var project models.Project
+	if err := initializers.DB.Select("id", "created_at").First(&project, projectID).Error; err != nil {
+		return time.Time{}, err
+	}
+	return truncateDay(project.CreatedAt), nil
+}
+
+//
+// --------------------------- TEMPLATES ---------------------------
+//
+
+type templatePayload struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	AnchorDate  string `json:"anchor_date"`     // début du projet, défaut sa création
+	Members     bool   `json:"include_members"` // propriétaire du projet seulement
+}
+
+// CreateProjectTemplate saves a project as a template (members)
+func CreateProjectTemplate(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+
+	var body templatePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Members && !canCopyMembers(&projectID, 0, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owner can copy the members of a project"})
+		return
+	}
+	anchor, err := projectAnchor(projectID, body.AnchorDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tpl, err := snapshotProject(projectID, anchor, body.Members)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	tpl.Name = body.Name
+	tpl.Description = body.Description
+	tpl.OwnerID = userID
+	if err := initializers.DB.Create(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save template"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"template": tpl})
+}
+
+// loadTemplate loads :templateId if the caller can use it: its owner or a
+// member of the project it was saved from
+func loadTemplate(c *gin.Context, withContent bool) (models.ProjectTemplate, uint, bool) {
+	var tpl models.ProjectTemplate
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return tpl, 0, false
+	}
+	id64, err := strconv.ParseUint(c.Param("templateId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return tpl, 0, false
+	}
+	q := initializers.DB
+	if withContent {
+		q = q.Preload("Tasks", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+			Preload("WipLimits").
+			Preload("Members.User")
+	}
+	if err := q.First(&tpl, uint(id64)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return tpl, 0, false
+	}
+	if tpl.OwnerID != userID {
+		isMember := false
+		if tpl.SourceProjectID != nil {
+			isMember, _ = IsProjectMember(*tpl.SourceProjectID, userID)
+		}
+		if !isMember {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return tpl, 0, false
+		}
+	}
+	return tpl, userID, true
+}
+
+// GetProjectTemplates lists the templates I can use
+func GetProjectTemplates(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	var templates []models.ProjectTemplate
+	if err := initializers.DB.
+		Where("owner_id = ? OR source_project_id IN (SELECT project_id FROM project_members WHERE user_id = ? AND deleted_at IS NULL)", userID, userID).
+		Order("name").
+		Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// GetProjectTemplate returns a template with its tasks, limits and members
+func GetProjectTemplate(c *gin.Context) {
+	tpl, _, ok := loadTemplate(c, true)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"template": tpl})
+}
+
+// DeleteProjectTemplate : seul l'auteur du modèle peut le supprimer
+func DeleteProjectTemplate(c *gin.Context) {
+	tpl, userID, ok := loadTemplate(c, false)
+	if !ok {
+		return
+	}
+	if tpl.OwnerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can delete a template"})
+		return
+	}
+	if err := initializers.DB.Delete(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete template"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "template deleted"})
+}
+
+// CreateProjectFromTemplate creates a project from a template; due dates
+// are shifted to start_date
+func CreateProjectFromTemplate(c *gin.Context) {
+	tpl, userID, ok := loadTemplate(c, true)
+	if !ok {
+		return
+	}
+	var body instantiatePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts, err := body.options()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.Members && len(tpl.Members) > 0 && !canCopyMembers(tpl.SourceProjectID, tpl.OwnerID, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owner of the source project can copy its members"})
+		return
+	}
+	createFromTemplate(c, tpl, userID, opts)
+}
@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+)
+
+// transferPayload : projet cible et correspondances optionnelles
+type transferPayload struct {
+	ProjectID uint   `json:"project_id"` // duplicate : 0 = même projet
+	Status    string `json:"status"`     // "" = statut actuel
+	Version   *uint  `json:"version"`    // transfer, si pas de header If-Match
+
+	// ancien user → membre du projet cible ; les autres non-membres sont retirés
+	AssigneeMap map[string]uint `json:"assignee_map"`
+
+	// duplicate seulement
+	Title            string `json:"title"`
+	IncludeAssignees *bool  `json:"include_assignees"` // défaut true
+}
+
+// errVersionConflict aborts a transaction when the task changed meanwhile
+var errVersionConflict = errors.New("version conflict")
+
+// transferPlan is what a task becomes in the destination project
+type transferPlan struct {
+	status    string
+	assignees []uint
+	estimate  string
+	points    *float64
+	sprintID  *uint
+	warnings  []string
+}
+
+// planTransfer maps a task to destProjectID: assignees are remapped or
+// dropped, the sprint is left (sprints belong to a project) and the
+// estimate is kept only if it fits the destination scale
+func planTransfer(task models.Task, destProjectID uint, body transferPayload, withAssignees bool) (transferPlan, error) {
+	plan := transferPlan{status: task.Status, warnings: []string{}}
+	if body.Status != "" {
+		plan.status = body.Status
+	}
+
+	if withAssignees {
+		var current []uint
+		if err := initializers.DB.Model(&models.TaskAssignee{}).Where("task_id = ?", task.ID).Order("id").Pluck("user_id", &current).Error; err != nil {
+			return plan, err
+		}
+		var members []uint
+		if err := initializers.DB.Model(&models.ProjectMember{}).Where("project_id = ?", destProjectID).Pluck("user_id", &members).Error; err != nil {
+			return plan, err
+		}
+		isMember := map[uint]bool{}
+		for _, id := range members {
+			isMember[id] = true
+		}
+		seen := map[uint]bool{}
+		for _, uid := range current {
+			target := uid
+			if mapped, ok := body.AssigneeMap[strconv.FormatUint(uint64(uid), 10)]; ok {
+				target = mapped
+			}
+			if !isMember[target] {
+				plan.warnings = append(plan.warnings, "assignee "+strconv.FormatUint(uint64(uid), 10)+" is not a member of the destination project, removed")
+				continue
+			}
+			if !seen[target] {
+				seen[target] = true
+				plan.assignees = append(plan.assignees, target)
+			}
+		}
+	}
+
+	if task.Estimate != "" {
+		scale, err := projectEstimateScale(destProjectID)
+		if err != nil {
+			return plan, err
+		}
+		if label, points, err := parseEstimate(scale, task.Estimate); err == nil {
+			plan.estimate, plan.points = label, points
+		} else {
+			plan.warnings = append(plan.warnings, "estimate "+strconv.Quote(task.Estimate)+" does not fit the destination scale, removed")
+		}
+	}
+
+	if task.SprintID != nil {
+		if destProjectID == task.ProjectID && validateSprintTask(destProjectID, *task.SprintID) == nil {
+			plan.sprintID = task.SprintID
+		} else {
+			plan.warnings = append(plan.warnings, "task left its sprint")
+		}
+	}
+	return plan, nil
+}
+
+// bindTransfer reads the payload and checks the destination project and status
+func bindTransfer(c *gin.Context, task models.Task, userID uint) (transferPayload, uint, bool) {
+	var body transferPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return body, 0, false
+	}
+	if body.Status != "" && !boardStatuses[body.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return body, 0, false
+	}
+	dest := body.ProjectID
+	if dest == 0 {
+		dest = task.ProjectID
+	}
+	isMember, err := IsProjectMember(dest, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a member of the destination project"})
+		return body, 0, false
+	}
+	return body, dest, true
+}
+
+// TransferTask moves a task to another project. The caller must be allowed
+// to update the task (creator or owner) and be a member of the destination.
+// History and work logs follow the task.
+func TransferTask(c *gin.Context) {
+	task, userID, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	if task.CreatorID != userID {
+		isOwner, err := IsProjectOwner(task.ProjectID, userID)
+		if err != nil || !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only creator or owner can move task"})
+			return
+		}
+	}
+	body, dest, ok := bindTransfer(c, task, userID)
+	if !ok {
+		return
+	}
+	if body.ProjectID == 0 || dest == task.ProjectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project_id must be another project"})
+		return
+	}
+
+	expected, ok := requireVersion(c, body.Version)
+	if !ok {
+		return
+	}
+	if task.Version != expected {
+		respondTaskConflict(c, task.ID)
+		return
+	}
+
+	plan, err := planTransfer(task, dest, body, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	// la tâche arrive dans la colonne du projet cible avec ses assignés remappés
+	wipWarnings, allowed := enforceAddedWipLimits(c, dest, plan.status, 1, oneTaskEach(plan.assignees))
+	if !allowed {
+		return
+	}
+
+	before := task
+	var removed, added []uint
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		rank, err := rankAtEndOfColumn(tx, dest, plan.status)
+		if err != nil {
+			return err
+		}
+		updated := map[string]interface{}{
+			"project_id":   dest,
+			"status":       plan.status,
+			"board_rank":   rank,
+			"sprint_id":    plan.sprintID,
+			"estimate":     plan.estimate,
+			"story_points": plan.points,
+			"version":      gorm.Expr("version + 1"),
+		}
+		res := tx.Model(&models.Task{}).Where("id = ? AND version = ?", task.ID, expected).Updates(updated)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errVersionConflict
+		}
+
+		// assignés : on garde ceux qui restent, on ajoute les correspondances
+		keep := map[uint]bool{}
+		for _, uid := range plan.assignees {
+			keep[uid] = true
+		}
+		var current []models.TaskAssignee
+		if err := tx.Where("task_id = ?", task.ID).Find(&current).Error; err != nil {
+			return err
+		}
+		for _, a := range current {
+			if keep[a.UserID] {
+				delete(keep, a.UserID)
+				continue
+			}
+			if err := tx.Delete(&a).Error; err != nil {
+				return err
+			}
+			removed = append(removed, a.UserID)
+		}
+		for _, uid := range plan.assignees {
+			if keep[uid] {
+				if err := tx.Create(&models.TaskAssignee{TaskID: task.ID, UserID: uid}).Error; err != nil {
+					return err
+				}
+				added = append(added, uid)
+			}
+		}
+		// les observateurs qui n'ont pas accès au projet cible ne suivent plus la tâche
+		if err := tx.Where("task_id = ? AND user_id NOT IN (SELECT user_id FROM project_members WHERE project_id = ? AND deleted_at IS NULL)", task.ID, dest).
+			Delete(&models.TaskWatcher{}).Error; err != nil {
+			return err
+		}
+		for _, uid := range plan.assignees {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.TaskWatcher{TaskID: task.ID, UserID: uid}).Error; err != nil {
+				return err
+			}
+		}
+
+		recordTaskChanges(tx, before, updated, userID)
+		for _, uid := range removed {
+			recordTaskActivity(tx, before, userID, models.ActivityUnassigned, "assignee", formatActivityValue(uid), "")
+		}
+		for _, uid := range added {
+			recordTaskActivity(tx, before, userID, models.ActivityAssigned, "assignee", "", formatActivityValue(uid))
+		}
+		// l'historique et le temps passé suivent la tâche
+		for _, m := range []interface{}{&models.TaskActivity{}, &models.WorkLog{}} {
+			if err := tx.Model(m).Where("task_id = ?", task.ID).Update("project_id", dest).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondTaskConflict(c, task.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not move task"})
+		return
+	}
+
+	if err := initializers.DB.Preload("Assignees.User").First(&task, task.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	data := gin.H{"task": task, "from_project_id": before.ProjectID, "to_project_id": dest}
+	emitProjectEvent(before.ProjectID, userID, realtime.TaskTransferred, data)
+	emitProjectEvent(dest, userID, realtime.TaskTransferred, data)
+	for _, uid := range removed {
+		emitProjectEvent(before.ProjectID, userID, realtime.TaskUnassigned, gin.H{"task_id": task.ID, "user_id": uid})
+	}
+	for _, uid := range added {
+		emitProjectEvent(dest, userID, realtime.TaskAssigned, gin.H{"task_id": task.ID, "user_id": uid})
+		notifyAssigned(task, uid, userID)
+	}
+	if task.Status != before.Status {
+		notifyStatusChange(task, userID, before.Status, task.Status)
+		afterTaskDone(task)
+	}
+
+	setETag(c, task.Version)
+	resp := gin.H{"task": task, "warnings": plan.warnings}
+	if len(wipWarnings) > 0 {
+		resp["wip_warnings"] = wipWarnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DuplicateTask copies a task into the same or another project (members of
+// both). The copy starts without history, work logs or recurrence.
+func DuplicateTask(c *gin.Context) {
+	task, userID, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	body, dest, ok := bindTransfer(c, task, userID)
+	if !ok {
+		return
+	}
+
+	withAssignees := body.IncludeAssignees == nil || *body.IncludeAssignees
+	plan, err := planTransfer(task, dest, body, withAssignees)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	if task.Recurrence != "" {
+		plan.warnings = append(plan.warnings, "recurrence is not copied")
+	}
+
+	copied := models.Task{
+		ProjectID:   dest,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      plan.status,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		SprintID:    plan.sprintID,
+		Estimate:    plan.estimate,
+		StoryPoints: plan.points,
+		CreatorID:   userID,
+	}
+	if body.Title != "" {
+		copied.Title = body.Title
+	}
+	if task.OriginalEstimate != nil {
+		original, remaining := *task.OriginalEstimate, *task.OriginalEstimate
+		copied.OriginalEstimate, copied.RemainingEstimate = &original, &remaining
+	}
+	wipWarnings, allowed := enforceAddedWipLimits(c, dest, copied.Status, 1, oneTaskEach(plan.assignees))
+	if !allowed {
+		return
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		rank, err := rankAtEndOfColumn(tx, dest, copied.Status)
+		if err != nil {
+			return err
+		}
+		copied.Rank = rank
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+		watchers := []models.TaskWatcher{{TaskID: copied.ID, UserID: userID}}
+		for _, uid := range plan.assignees {
+			if err := tx.Create(&models.TaskAssignee{TaskID: copied.ID, UserID: uid}).Error; err != nil {
+				return err
+			}
+			watchers = append(watchers, models.TaskWatcher{TaskID: copied.ID, UserID: uid})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&watchers).Error; err != nil {
+			return err
+		}
+		recordTaskActivity(tx, copied, userID, models.ActivityCreated, "", "", copied.Title)
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not duplicate task"})
+		return
+	}
+
+	initializers.DB.Preload("Assignees.User").First(&copied, copied.ID)
+	emitProjectEvent(dest, userID, realtime.TaskCreated, gin.H{"task": copied, "duplicated_from": task.ID})
+	for _, uid := range plan.assignees {
+		notifyAssigned(copied, uid, userID)
+	}
+
+	setETag(c, copied.Version)
+	resp := gin.H{"task": copied, "warnings": plan.warnings}
+	if len(wipWarnings) > 0 {
+		resp["wip_warnings"] = wipWarnings
+	}
+	c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// velocityPeriod is one sprint or one week of the velocity report
+type velocityPeriod struct {
+	SprintID        *uint      `json:"sprint_id,omitempty"`
+	Name            string     `json:"name,omitempty"`
+	State           string     `json:"state,omitempty"`
+	Start           *time.Time `json:"start"`
+	End             *time.Time `json:"end"`
+	CommittedPoints *float64   `json:"committed_points,omitempty"`
+	CommittedTasks  *int       `json:"committed_tasks,omitempty"`
+	CompletedPoints float64    `json:"completed_points"`
+	CompletedTasks  int        `json:"completed_tasks"`
+	RollingAverage  float64    `json:"rolling_average"` // points complétés, moyenne sur `window` périodes
+}
+
+// queryInt reads a positive int query param, bounded by max
+func queryInt(c *gin.Context, name string, def, max int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > max {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return n, true
+}
+
+// GetProjectVelocity reports completed story points per sprint or per week.
+// Query: by=sprint|week (default sprint), weeks (default 12), window (default 3)
+func GetProjectVelocity(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	by := c.DefaultQuery("by", "sprint")
+	if by != "sprint" && by != "week" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "by must be sprint or week"})
+		return
+	}
+	weeks, ok := queryInt(c, "weeks", 12, 104)
+	if !ok {
+		return
+	}
+	window, ok := queryInt(c, "window", 3, 52)
+	if !ok {
+		return
+	}
+
+	done, err := doneTasks(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+
+	var periods []velocityPeriod
+	if by == "week" {
+		periods = weeklyVelocity(done, weeks)
+	} else {
+		periods, err = sprintVelocity(projectID, done)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load sprints"})
+			return
+		}
+	}
+
+	// moyenne glissante des points complétés
+	sum := 0.0
+	for i := range periods {
+		sum += periods[i].CompletedPoints
+		if i >= window {
+			sum -= periods[i-window].CompletedPoints
+		}
+		n := window
+		if i+1 < window {
+			n = i + 1
+		}
+		periods[i].RollingAverage = round1(sum / float64(n))
+	}
+
+	average := 0.0
+	if len(periods) > 0 {
+		average = periods[len(periods)-1].RollingAverage
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"by":      by,
+		"window":  window,
+		"periods": periods,
+		"average": average,
+	})
+}
+
+// weeklyVelocity buckets completed tasks into the last `weeks` weeks (Monday start)
+func weeklyVelocity(done []doneTask, weeks int) []velocityPeriod {
+	first := startOfWeek(time.Now()).AddDate(0, 0, -7*(weeks-1))
+	periods := make([]velocityPeriod, weeks)
+	for i := range periods {
+		start := first.AddDate(0, 0, 7*i)
+		end := start.AddDate(0, 0, 7)
+		periods[i].Start = &start
+		periods[i].End = &end
+	}
+	for _, t := range done {
+		at := t.DoneAt.In(first.Location())
+		if at.Before(first) {
+			continue
+		}
+		i := int(startOfWeek(at).Sub(first).Hours()+12) / (24 * 7)
+		if i >= weeks {
+			continue
+		}
+		periods[i].CompletedPoints += t.points()
+		periods[i].CompletedTasks++
+	}
+	return periods
+}
+
+// sprintVelocity reports started sprints in start order: committed scope
+// (snapshot at start) against tasks of the sprint done before it closed
+func sprintVelocity(projectID uint, done []doneTask) ([]velocityPeriod, error) {
+	var sprints []models.Sprint
+	if err := initializers.DB.
+		Where("project_id = ? AND state <> ?", projectID, models.SprintPlanned).
+		Order("started_at").Order("id").
+		Find(&sprints).Error; err != nil {
+		return nil, err
+	}
+
+	periods := make([]velocityPeriod, 0, len(sprints))
+	for _, s := range sprints {
+		id := s.ID
+		p := velocityPeriod{
+			SprintID:        &id,
+			Name:            s.Name,
+			State:           s.State,
+			Start:           s.StartedAt,
+			End:             s.ClosedAt,
+			CommittedPoints: s.CommittedPoints,
+			CommittedTasks:  s.CommittedTasks,
+		}
+		// sprint démarré avant l'instantané : périmètre actuel
+		if p.CommittedPoints == nil {
+			var scope struct {
+				Points float64
+				Tasks  int
+			}
+			if err := initializers.DB.Model(&models.Task{}).
+				Select("COALESCE(SUM(story_points), 0) AS points, COUNT(*) AS tasks").
+				Where("sprint_id = ?", s.ID).
+				Scan(&scope).Error; err != nil {
+				return nil, err
+			}
+			p.CommittedPoints = &scope.Points
+			p.CommittedTasks = &scope.Tasks
+		}
+		for _, t := range done {
+			if t.SprintID == nil || *t.SprintID != s.ID {
+				continue
+			}
+			if s.ClosedAt != nil && t.DoneAt.After(*s.ClosedAt) {
+				continue
+			}
+			p.CompletedPoints += t.points()
+			p.CompletedTasks++
+		}
+		periods = append(periods, p)
+	}
+	return periods, nil
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// watchTask makes userID follow a task; already watching is not an error
+func watchTask(taskID, userID uint) {
+	w := models.TaskWatcher{TaskID: taskID, UserID: userID}
+	if err := initializers.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&w).Error; err != nil {
+		log.Printf("could not add watcher %d to task %d: %v", userID, taskID, err)
+	}
+}
+
+// loadMemberTask loads :taskId and checks that the caller is a member of its project
+func loadMemberTask(c *gin.Context) (models.Task, uint, bool) {
+	var task models.Task
+	tid64, err := strconv.ParseUint(c.Param("taskId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return task, 0, false
+	}
+
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return task, 0, false
+	}
+
+	if err := initializers.DB.First(&task, uint(tid64)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return task, 0, false
+	}
+
+	isMember, err := IsProjectMember(task.ProjectID, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+		return task, 0, false
+	}
+	return task, userID, true
+}
+
+// GetTask returns a task with its assignees and watchers
+func GetTask(c *gin.Context) {
+	task, userID, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+
+	if err := initializers.DB.
+		Preload("Assignees.User").
+		Preload("Watchers", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Preload("Watchers.User").
+		First(&task, task.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load task"})
+		return
+	}
+
+	watching := false
+	for _, w := range task.Watchers {
+		if w.UserID == userID {
+			watching = true
+		}
+	}
+
+	setETag(c, task.Version)
+	c.JSON(http.StatusOK, gin.H{"task": task, "watching": watching})
+}
+
+// WatchTask : le user courant suit la tâche
+func WatchTask(c *gin.Context) {
+	task, userID, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	watchTask(task.ID, userID)
+	c.JSON(http.StatusOK, gin.H{"message": "watching", "watching": true})
+}
+
+// UnwatchTask : le user courant ne suit plus la tâche
+func UnwatchTask(c *gin.Context) {
+	task, userID, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	if err := initializers.DB.
+		Where("task_id = ? AND user_id = ?", task.ID, userID).
+		Delete(&models.TaskWatcher{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not unwatch"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "not watching", "watching": false})
+}
+
+// GetTaskWatchers lists the users watching a task
+func GetTaskWatchers(c *gin.Context) {
+	task, _, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	var watchers []models.TaskWatcher
+	if err := initializers.DB.Preload("User").
+		Where("task_id = ?", task.ID).
+		Order("created_at, id").
+		Find(&watchers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load watchers"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"watchers": watchers})
+}
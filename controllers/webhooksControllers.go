@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/webhooks"
+)
+
+type webhookPayload struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required,min=1"` // event types or "*"
+	Active *bool    `json:"active"`
+}
+
+// validateWebhook checks the URL and the event types, returns the stored event list
+func validateWebhook(body webhookPayload) (string, error) {
+	if err := webhooks.CheckURL(body.URL); err != nil {
+		return "", err
+	}
+
+	known := map[string]bool{"*": true}
+	for _, t := range realtime.EventTypes {
+		known[t] = true
+	}
+	for _, e := range body.Events {
+		if !known[e] {
+			return "", errors.New("unknown event type " + e)
+		}
+	}
+	return strings.Join(body.Events, ","), nil
+}
+
+// loadOwnedWebhook checks :projectId ownership and loads :webhookId of that project.
+// It writes the error response itself.
+func loadOwnedWebhook(c *gin.Context) (models.Webhook, bool) {
+	var hook models.Webhook
+
+	projectID, ok := requireProjectOwnerParam(c, "only owner can manage webhooks")
+	if !ok {
+		return hook, false
+	}
+	wid64, err := strconv.ParseUint(c.Param("webhookId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return hook, false
+	}
+	if err := initializers.DB.Where("project_id = ?", projectID).First(&hook, uint(wid64)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return hook, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return hook, false
+	}
+	return hook, true
+}
+
+// requireProjectOwnerParam parses :projectId and checks the caller owns it
+func requireProjectOwnerParam(c *gin.Context, forbidden string) (uint, bool) {
+	pid64, err := strconv.ParseUint(c.Param("projectId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return 0, false
+	}
+	projectID := uint(pid64)
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return 0, false
+	}
+	isOwner, err := IsProjectOwner(projectID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return 0, false
+	}
+	if !isOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": forbidden})
+		return 0, false
+	}
+	return projectID, true
+}
+
+// GetWebhooks lists the webhooks of a project (owner only)
+func GetWebhooks(c *gin.Context) {
+	projectID, ok := requireProjectOwnerParam(c, "only owner can manage webhooks")
+	if !ok {
+		return
+	}
+	var hooks []models.Webhook
+	if err := initializers.DB.Where("project_id = ?", projectID).Order("id").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load webhooks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": hooks})
+}
+
+// CreateWebhook registers an endpoint. The signing secret is only returned here.
+func CreateWebhook(c *gin.Context) {
+	projectID, ok := requireProjectOwnerParam(c, "only owner can manage webhooks")
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+
+	var body webhookPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := validateWebhook(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate secret"})
+		return
+	}
+
+	hook := models.Webhook{
+		ProjectID:   projectID,
+		URL:         body.URL,
+		Secret:      secret,
+		Events:      events,
+		Active:      body.Active == nil || *body.Active,
+		CreatedByID: userID,
+	}
+	if err := initializers.DB.Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create webhook"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"webhook": hook, "secret": secret})
+}
+
+// UpdateWebhook changes url/events/active; re-activating clears the failure counter
+func UpdateWebhook(c *gin.Context) {
+	hook, ok := loadOwnedWebhook(c)
+	if !ok {
+		return
+	}
+
+	var body webhookPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := validateWebhook(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hook.URL = body.URL
+	hook.Events = events
+	if body.Active != nil {
+		if *body.Active && !hook.Active {
+			hook.ConsecutiveFailures = 0
+			hook.DisabledAt = nil
+		}
+		hook.Active = *body.Active
+	}
+	if err := initializers.DB.Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": hook})
+}
+
+// RotateWebhookSecret replaces the signing secret and returns the new one
+func RotateWebhookSecret(c *gin.Context) {
+	hook, ok := loadOwnedWebhook(c)
+	if !ok {
+		return
+	}
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate secret"})
+		return
+	}
+	if err := initializers.DB.Model(&hook).Update("secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": hook, "secret": secret})
+}
+
+// DeleteWebhook removes the endpoint; its pending deliveries are not sent anymore
+func DeleteWebhook(c *gin.Context) {
+	hook, ok := loadOwnedWebhook(c)
+	if !ok {
+		return
+	}
+	if err := initializers.DB.Delete(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+// PingWebhook queues a "ping" delivery, handy to test a local receiver
+func PingWebhook(c *gin.Context) {
+	hook, ok := loadOwnedWebhook(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDFromCtx(c)
+
+	body, _ := json.Marshal(gin.H{
+		"event":      "ping",
+		"project_id": hook.ProjectID,
+		"actor_id":   userID,
+		"data":       gin.H{"webhook_id": hook.ID},
+		"created_at": time.Now(),
+	})
+	d := models.WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventType:     "ping",
+		Payload:       string(body),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := initializers.DB.Create(&d).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not queue ping"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"delivery": d})
+}
+
+// GetWebhookDeliveries returns the delivery log, newest first (?page, ?per_page, ?status)
+func GetWebhookDeliveries(c *gin.Context) {
+	hook, ok := loadOwnedWebhook(c)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		return
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultActivityPageSize)))
+	if err != nil || perPage < 1 || perPage > maxActivityPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid per_page"})
+		return
+	}
+
+	q := initializers.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.ID)
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", strings.ToUpper(status))
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	var deliveries []models.WebhookDelivery
+	if err := q.Order("id DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"page":       page,
+		"per_page":   perPage,
+		"total":      total,
+	})
+}
+
+// RedeliverWebhook queues a past delivery again
+func RedeliverWebhook(c *gin.Context) {
+	hook, ok := loadOwnedWebhook(c)
+	if !ok {
+		return
+	}
+	did64, err := strconv.ParseUint(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+
+	var d models.WebhookDelivery
+	if err := initializers.DB.Where("webhook_id = ?", hook.ID).First(&d, uint(did64)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		return
+	}
+	again, err := webhooks.Redeliver(d)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not queue delivery"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"delivery": again})
+}
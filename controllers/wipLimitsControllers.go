@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// wipViolation describes a limit that a move would exceed
+type wipViolation struct {
+	Status     string `json:"status"`
+	AssigneeID *uint  `json:"assignee_id,omitempty"`
+	MaxTasks   int    `json:"max_tasks"`
+	Count      int64  `json:"count"` // tasks in the column once the move is done
+}
+
+// wipUsage is the current count of a column (or of an assignee in a column)
+type wipUsage struct {
+	Status     string `json:"status"`
+	AssigneeID *uint  `json:"assignee_id,omitempty"`
+	Count      int64  `json:"count"`
+	MaxTasks   *int   `json:"max_tasks"`
+	Exceeded   bool   `json:"exceeded"`
+}
+
+// countTasksInStatus counts the tasks of a column, optionally only those
+// assigned to assigneeID, leaving out the task being moved
+func countTasksInStatus(projectID uint, status string, assigneeID *uint, excludeTaskID uint) (int64, error) {
+	q := initializers.DB.Model(&models.Task{}).
+		Where("project_id = ? AND status = ? AND id <> ?", projectID, status, excludeTaskID)
+	if assigneeID != nil {
+		q = q.Where("id IN (SELECT task_id FROM task_assignees WHERE user_id = ? AND deleted_at IS NULL)", *assigneeID)
+	}
+	var n int64
+	err := q.Count(&n).Error
+	return n, err
+}
+
+// checkWipLimits returns the project's WIP mode and the limits that moving
+// task into status would exceed. Nothing is checked when the mode is OFF.
+func checkWipLimits(task models.Task, status string) (string, []wipViolation, error) {
+	var project models.Project
+	if err := initializers.DB.Select("id", "wip_mode").First(&project, task.ProjectID).Error; err != nil {
+		return "", nil, err
+	}
+	if project.WipMode == models.WipModeOff {
+		return project.WipMode, nil, nil
+	}
+
+	var limits []models.WipLimit
+	if err := initializers.DB.Where("project_id = ? AND status = ?", task.ProjectID, status).Find(&limits).Error; err != nil {
+		return project.WipMode, nil, err
+	}
+
+	violations := []wipViolation{}
+	for _, l := range limits {
+		if l.AssigneeID != nil {
+			// only relevant if the moved task belongs to that assignee
+			var assigned int64
+			if err := initializers.DB.Model(&models.TaskAssignee{}).
+				Where("task_id = ? AND user_id = ?", task.ID, *l.AssigneeID).
+				Count(&assigned).Error; err != nil {
+				return project.WipMode, nil, err
+			}
+			if assigned == 0 {
+				continue
+			}
+		}
+		n, err := countTasksInStatus(task.ProjectID, status, l.AssigneeID, task.ID)
+		if err != nil {
+			return project.WipMode, nil, err
+		}
+		if n+1 > int64(l.MaxTasks) {
+			violations = append(violations, wipViolation{
+				Status:     status,
+				AssigneeID: l.AssigneeID,
+				MaxTasks:   l.MaxTasks,
+				Count:      n + 1,
+			})
+		}
+	}
+	return project.WipMode, violations, nil
+}
+
+// enforceWipLimits runs checkWipLimits for a status change. It writes the
+// response and returns false when the move is blocked; otherwise it returns
+// the violations to report as warnings.
+func enforceWipLimits(c *gin.Context, task models.Task, status string) ([]wipViolation, bool) {
+	mode, violations, err := checkWipLimits(task, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return nil, false
+	}
+	if len(violations) > 0 && mode == models.WipModeBlock {
+		c.JSON(http.StatusConflict, gin.H{"error": "WIP limit exceeded", "violations": violations})
+		return nil, false
+	}
+	return violations, true
+}
+
+// computeWipUsage returns the count of every column (with its limit if any)
+// followed by the per-assignee limits
+func computeWipUsage(projectID uint) ([]wipUsage, error) {
+	db := initializers.DB
+
+	type statusCount struct {
+		Status string
+		N      int64
+	}
+	var counts []statusCount
+	if err := db.Model(&models.Task{}).
+		Select("status, COUNT(*) AS n").
+		Where("project_id = ?", projectID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	countByStatus := map[string]int64{}
+	for _, sc := range counts {
+		countByStatus[sc.Status] = sc.N
+	}
+
+	var limits []models.WipLimit
+	if err := db.Where("project_id = ?", projectID).Order("status, assignee_id").Find(&limits).Error; err != nil {
+		return nil, err
+	}
+	columnLimit := map[string]int{}
+	for _, l := range limits {
+		if l.AssigneeID == nil {
+			columnLimit[l.Status] = l.MaxTasks
+		}
+	}
+
+	usage := []wipUsage{}
+	for _, status := range []string{models.TaskStatusTodo, models.TaskStatusDoing, models.TaskStatusDone} {
+		u := wipUsage{Status: status, Count: countByStatus[status]}
+		if max, ok := columnLimit[status]; ok {
+			u.MaxTasks = &max
+			u.Exceeded = u.Count > int64(max)
+		}
+		usage = append(usage, u)
+	}
+	for _, l := range limits {
+		if l.AssigneeID == nil {
+			continue
+		}
+		n, err := countTasksInStatus(projectID, l.Status, l.AssigneeID, 0)
+		if err != nil {
+			return nil, err
+		}
+		max := l.MaxTasks
+		usage = append(usage, wipUsage{
+			Status:     l.Status,
+			AssigneeID: l.AssigneeID,
+			Count:      n,
+			MaxTasks:   &max,
+			Exceeded:   n > int64(max),
+		})
+	}
+	return usage, nil
+}
+
+//
+// --------------------------- ENDPOINTS ---------------------------
+//
+
+type wipLimitItem struct {
+	Status     string `json:"status" binding:"required"`
+	AssigneeID *uint  `json:"assignee_id"`
+	MaxTasks   int    `json:"max_tasks" binding:"required,min=1"`
+}
+
+// wipLimitsPayload replaces all limits of the project (and optionally the mode)
+type wipLimitsPayload struct {
+	Mode   string         `json:"mode"` // OFF, WARN or BLOCK
+	Limits []wipLimitItem `json:"limits" binding:"dive"`
+}
+
+// GetWipLimits : any member can read limits and current usage
+func GetWipLimits(c *gin.Context) {
+	pidStr := c.Param("projectId")
+	pid64, err := strconv.ParseUint(pidStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	projectID := uint(pid64)
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	isMember, err := IsProjectMember(projectID, userID)
+	if err != nil || !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a project member"})
+		return
+	}
+
+	var project models.Project
+	if err := initializers.DB.First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+	var limits []models.WipLimit
+	if err := initializers.DB.Where("project_id = ?", projectID).Find(&limits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	usage, err := computeWipUsage(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mode": project.WipMode, "limits": limits, "usage": usage})
+}
+
+// SetWipLimits : only OWNER can change limits
+func SetWipLimits(c *gin.Context) {
+	pidStr := c.Param("projectId")
+	pid64, err := strconv.ParseUint(pidStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	projectID := uint(pid64)
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	isOwner, err := IsProjectOwner(projectID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	if !isOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owner can change WIP limits"})
+		return
+	}
+
+	var body wipLimitsPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch body.Mode {
+	case "", models.WipModeOff, models.WipModeWarn, models.WipModeBlock:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mode"})
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, l := range body.Limits {
+		if !boardStatuses[l.Status] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status " + l.Status})
+			return
+		}
+		key := l.Status
+		if l.AssigneeID != nil {
+			key += "/" + strconv.FormatUint(uint64(*l.AssigneeID), 10)
+			isMember, err := IsProjectMember(projectID, *l.AssigneeID)
+			if err != nil || !isMember {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "assignee is not a project member"})
+				return
+			}
+		}
+		if seen[key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate limit for " + key})
+			return
+		}
+		seen[key] = true
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if body.Mode != "" {
+			if err := tx.Model(&models.Project{}).Where("id = ?", projectID).Updates(map[string]interface{}{
+				"wip_mode": body.Mode,
+				"version":  gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.WipLimit{}).Error; err != nil {
+			return err
+		}
+		for _, l := range body.Limits {
+			limit := models.WipLimit{
+				ProjectID:  projectID,
+				Status:     l.Status,
+				AssigneeID: l.AssigneeID,
+				MaxTasks:   l.MaxTasks,
+			}
+			if err := tx.Create(&limit).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save WIP limits"})
+		return
+	}
+
+	usage, err := computeWipUsage(projectID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "WIP limits saved", "warning": "could not compute usage"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "WIP limits saved", "usage": usage})
+}
+
+// checkBulkWipLimits is checkWipLimits for a batch of tasks moved together
+// into status: the tasks already in that column are not counted twice
+func checkBulkWipLimits(projectID uint, status string, taskIDs []uint) (string, []wipViolation, error) {
+	return checkAddedWipLimits(projectID, status, taskIDs, 0, nil)
+}
+
+// checkAddedWipLimits also counts tasks that are not in the column yet
+// (imports, copies, transfers) or not assigned yet: added tasks land in
+// status, and addedByAssignee[uid] tasks of the column get uid as assignee.
+// Without taskIDs, only the limits these additions touch are checked.
+func checkAddedWipLimits(projectID uint, status string, taskIDs []uint, added int, addedByAssignee map[uint]int) (string, []wipViolation, error) {
+	var project models.Project
+	if err := initializers.DB.Select("id", "wip_mode").First(&project, projectID).Error; err != nil {
+		return "", nil, err
+	}
+	if project.WipMode == models.WipModeOff || (len(taskIDs) == 0 && added == 0) {
+		return project.WipMode, nil, nil
+	}
+
+	var limits []models.WipLimit
+	if err := initializers.DB.Where("project_id = ? AND status = ?", projectID, status).Find(&limits).Error; err != nil {
+		return project.WipMode, nil, err
+	}
+
+	violations := []wipViolation{}
+	for _, l := range limits {
+		extra := added
+		if l.AssigneeID != nil {
+			extra = addedByAssignee[*l.AssigneeID]
+		}
+		if len(taskIDs) == 0 && extra == 0 {
+			continue
+		}
+		q := initializers.DB.Model(&models.Task{}).Where("project_id = ?", projectID)
+		if len(taskIDs) > 0 {
+			q = q.Where("(status = ? OR id IN ?)", status, taskIDs)
+		} else {
+			q = q.Where("status = ?", status)
+		}
+		if l.AssigneeID != nil {
+			q = q.Where("id IN (SELECT task_id FROM task_assignees WHERE user_id = ? AND deleted_at IS NULL)", *l.AssigneeID)
+		}
+		var n int64
+		if err := q.Count(&n).Error; err != nil {
+			return project.WipMode, nil, err
+		}
+		n += int64(extra)
+		if n > int64(l.MaxTasks) {
+			violations = append(violations, wipViolation{
+				Status:     status,
+				AssigneeID: l.AssigneeID,
+				MaxTasks:   l.MaxTasks,
+				Count:      n,
+			})
+		}
+	}
+	return project.WipMode, violations, nil
+}
+
+// enforceAddedWipLimits is enforceWipLimits for checkAddedWipLimits
+// (copies, transfers, new assignees of a task)
+func enforceAddedWipLimits(c *gin.Context, projectID uint, status string, added int, addedByAssignee map[uint]int) ([]wipViolation, bool) {
+	mode, violations, err := checkAddedWipLimits(projectID, status, nil, added, addedByAssignee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return nil, false
+	}
+	if len(violations) > 0 && mode == models.WipModeBlock {
+		c.JSON(http.StatusConflict, gin.H{"error": "WIP limit exceeded", "violations": violations})
+		return nil, false
+	}
+	return violations, true
+}
+
+// oneTaskEach : chaque assigné reçoit une tâche de plus
+func oneTaskEach(userIDs []uint) map[uint]int {
+	m := map[uint]int{}
+	for _, uid := range userIDs {
+		m[uid] = 1
+	}
+	return m
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// maxCapacityHours : une semaine compte 168 heures
+const maxCapacityHours = 168
+
+// workloadRow is one open task of one assignee
+type workloadRow struct {
+	TaskID            uint
+	ProjectID         uint
+	UserID            uint
+	DueDate           *time.Time
+	OriginalEstimate  *int
+	RemainingEstimate *int
+	StoryPoints       *float64
+	Share             int // nombre d'assignés, l'effort est partagé
+}
+
+// effortHours : reste à faire, sinon estimation initiale, divisé entre les assignés
+func (r workloadRow) effortHours() float64 {
+	minutes := r.RemainingEstimate
+	if minutes == nil {
+		minutes = r.OriginalEstimate
+	}
+	if minutes == nil || r.Share == 0 {
+		return 0
+	}
+	return float64(*minutes) / 60 / float64(r.Share)
+}
+
+func (r workloadRow) points() float64 {
+	if r.StoryPoints == nil || r.Share == 0 {
+		return 0
+	}
+	return *r.StoryPoints / float64(r.Share)
+}
+
+type workloadLoad struct {
+	OpenTasks   int     `json:"open_tasks"`
+	EffortHours float64 `json:"effort_hours"`
+	Points      float64 `json:"points"`
+	Overdue     int     `json:"overdue"`
+}
+
+func (l *workloadLoad) add(r workloadRow, now time.Time) {
+	l.OpenTasks++
+	l.EffortHours += r.effortHours()
+	l.Points += r.points()
+	if r.DueDate != nil && r.DueDate.Before(now) {
+		l.Overdue++
+	}
+}
+
+func (l *workloadLoad) round() {
+	l.EffortHours = round1(l.EffortHours)
+	l.Points = round1(l.Points)
+}
+
+type workloadWeek struct {
+	WeekStart string `json:"week_start"`
+	workloadLoad
+	OverAllocated bool `json:"over_allocated"`
+}
+
+// workloadSheet spreads open tasks over weeks by due date. Overdue tasks
+// count in the current week; tasks due after the horizon go to Later.
+type workloadSheet struct {
+	CapacityHours *float64       `json:"capacity_hours"`
+	Total         workloadLoad   `json:"total"`
+	Weeks         []workloadWeek `json:"weeks"`
+	Later         workloadLoad   `json:"later"`
+	Unscheduled   workloadLoad   `json:"unscheduled"`
+	OverAllocated bool           `json:"over_allocated"`
+
+	first time.Time
+}
+
+func newWorkloadSheet(first time.Time, weeks int) *workloadSheet {
+	s := &workloadSheet{first: first, Weeks: make([]workloadWeek, weeks)}
+	for i := range s.Weeks {
+		s.Weeks[i].WeekStart = first.AddDate(0, 0, 7*i).Format(filterDateLayout)
+	}
+	return s
+}
+
+func (s *workloadSheet) add(r workloadRow, now time.Time) {
+	s.Total.add(r, now)
+	if r.DueDate == nil {
+		s.Unscheduled.add(r, now)
+		return
+	}
+	i := 0
+	if due := r.DueDate.In(s.first.Location()); !due.Before(s.first) {
+		i = int(startOfWeek(due).Sub(s.first).Hours()+12) / (24 * 7)
+	}
+	if i >= len(s.Weeks) {
+		s.Later.add(r, now)
+		return
+	}
+	s.Weeks[i].add(r, now)
+}
+
+// finish rounds the totals and flags the weeks above capacity
+func (s *workloadSheet) finish(capacity *float64) {
+	s.CapacityHours = capacity
+	s.Total.round()
+	s.Later.round()
+	s.Unscheduled.round()
+	for i := range s.Weeks {
+		w := &s.Weeks[i]
+		w.round()
+		if capacity != nil && w.EffortHours > *capacity {
+			w.OverAllocated = true
+			s.OverAllocated = true
+		}
+	}
+}
+
+// loadWorkloadRows returns the open (not DONE) assigned tasks matching scope
+func loadWorkloadRows(scope func(*gorm.DB) *gorm.DB) ([]workloadRow, error) {
+	var rows []workloadRow
+	q := initializers.DB.Table("task_assignees").
+		Select(`tasks.id AS task_id, tasks.project_id, task_assignees.user_id, tasks.due_date,
+			tasks.original_estimate, tasks.remaining_estimate, tasks.story_points,
+			(SELECT COUNT(*) FROM task_assignees ta WHERE ta.task_id = tasks.id AND ta.deleted_at IS NULL) AS share`).
+		Joins("JOIN tasks ON tasks.id = task_assignees.task_id AND tasks.deleted_at IS NULL").
+		Where("task_assignees.deleted_at IS NULL AND tasks.status <> ?", models.TaskStatusDone)
+	err := scope(q).Scan(&rows).Error
+	return rows, err
+}
+
+// workloadHorizon reads ?weeks= (default 4) and returns the first Monday
+func workloadHorizon(c *gin.Context) (time.Time, int, bool) {
+	weeks, ok := queryInt(c, "weeks", 4, 26)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	return startOfWeek(truncateDay(time.Now())), weeks, true
+}
+
+type memberWorkload struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	*workloadSheet
+}
+
+// GetProjectWorkload : charge de chaque membre par semaine, comparée à sa capacité. Query: weeks
+func GetProjectWorkload(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	first, weeks, ok := workloadHorizon(c)
+	if !ok {
+		return
+	}
+
+	var members []models.ProjectMember
+	if err := initializers.DB.Preload("User").Where("project_id = ?", projectID).Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load members"})
+		return
+	}
+	rows, err := loadWorkloadRows(func(q *gorm.DB) *gorm.DB {
+		return q.Where("tasks.project_id = ?", projectID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+
+	now := time.Now()
+	sheets := map[uint]*workloadSheet{}
+	for _, m := range members {
+		sheets[m.UserID] = newWorkloadSheet(first, weeks)
+	}
+	for _, r := range rows {
+		// assignés qui ne sont plus membres : ignorés
+		if s := sheets[r.UserID]; s != nil {
+			s.add(r, now)
+		}
+	}
+
+	out := make([]memberWorkload, 0, len(members))
+	overAllocated := 0
+	for _, m := range members {
+		s := sheets[m.UserID]
+		s.finish(m.CapacityHours)
+		if s.OverAllocated {
+			overAllocated++
+		}
+		out = append(out, memberWorkload{UserID: m.UserID, Name: m.User.Name, Role: m.Role, workloadSheet: s})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Total.EffortHours > out[j].Total.EffortHours })
+
+	c.JSON(http.StatusOK, gin.H{
+		"weeks":          weeks,
+		"members":        out,
+		"over_allocated": overAllocated,
+	})
+}
+
+type projectWorkload struct {
+	ProjectID uint   `json:"project_id"`
+	Name      string `json:"name"`
+	*workloadSheet
+}
+
+// GetMyWorkload : charge de l'utilisateur courant sur tous ses projets. Query: weeks
+func GetMyWorkload(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	first, weeks, ok := workloadHorizon(c)
+	if !ok {
+		return
+	}
+
+	var memberships []models.ProjectMember
+	if err := initializers.DB.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load projects"})
+		return
+	}
+	projectIDs := make([]uint, 0, len(memberships))
+	for _, m := range memberships {
+		projectIDs = append(projectIDs, m.ProjectID)
+	}
+	var projects []models.Project
+	if len(projectIDs) > 0 {
+		if err := initializers.DB.Select("id", "name").Where("id IN ?", projectIDs).Find(&projects).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load projects"})
+			return
+		}
+	}
+	names := map[uint]string{}
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+
+	rows, err := loadWorkloadRows(func(q *gorm.DB) *gorm.DB {
+		return q.Where("task_assignees.user_id = ?", userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load tasks"})
+		return
+	}
+
+	now := time.Now()
+	total := newWorkloadSheet(first, weeks)
+	sheets := map[uint]*workloadSheet{}
+	for _, m := range memberships {
+		sheets[m.ProjectID] = newWorkloadSheet(first, weeks)
+	}
+	for _, r := range rows {
+		s := sheets[r.ProjectID]
+		if s == nil {
+			continue
+		}
+		s.add(r, now)
+		total.add(r, now)
+	}
+
+	// capacité totale : somme des capacités renseignées
+	var capacity *float64
+	byProject := make([]projectWorkload, 0, len(memberships))
+	for _, m := range memberships {
+		if _, ok := names[m.ProjectID]; !ok {
+			continue
+		}
+		if m.CapacityHours != nil {
+			sum := *m.CapacityHours
+			if capacity != nil {
+				sum += *capacity
+			}
+			capacity = &sum
+		}
+		s := sheets[m.ProjectID]
+		s.finish(m.CapacityHours)
+		byProject = append(byProject, projectWorkload{ProjectID: m.ProjectID, Name: names[m.ProjectID], workloadSheet: s})
+	}
+	sort.Slice(byProject, func(i, j int) bool { return byProject[i].Total.EffortHours > byProject[j].Total.EffortHours })
+	total.finish(capacity)
+
+	c.JSON(http.StatusOK, gin.H{
+		"weeks":      weeks,
+		"workload":   total,
+		"by_project": byProject,
+	})
+}
+
+type capacityPayload struct {
+	CapacityHours *float64 `json:"capacity_hours"`
+}
+
+// SetMemberCapacity sets the weekly capacity of a member (owner, or the member
+// for themselves). capacity_hours: null clears it.
+func SetMemberCapacity(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	uid64, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	targetID := uint(uid64)
+	userID, _ := getUserIDFromCtx(c)
+	if targetID != userID {
+		owner, err := IsProjectOwner(projectID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+			return
+		}
+		if !owner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only owner can set the capacity of other members"})
+			return
+		}
+	}
+
+	var body capacityPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.CapacityHours != nil && (*body.CapacityHours < 0 || *body.CapacityHours > maxCapacityHours) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "capacity_hours must be between 0 and 168"})
+		return
+	}
+
+	var member models.ProjectMember
+	if err := initializers.DB.Where("project_id = ? AND user_id = ?", projectID, targetID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+		return
+	}
+	if err := initializers.DB.Model(&member).Update("capacity_hours", body.CapacityHours).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update capacity"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": targetID, "capacity_hours": body.CapacityHours})
+}
@@ -0,0 +1,503 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/export"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// defaultReportDays: range of a time report without from/to
+const defaultReportDays = 30
+
+// worklogPayload : start/end, ou start + durée, ou durée seule (finie maintenant)
+type worklogPayload struct {
+	StartedAt       *time.Time `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationMinutes *int       `json:"duration_minutes"`
+	Note            *string    `json:"note"`
+}
+
+// worklogTimes resolves the start and end of a manual entry
+func worklogTimes(body worklogPayload) (time.Time, time.Time, error) {
+	switch {
+	case body.EndedAt != nil:
+		if body.StartedAt == nil {
+			return time.Time{}, time.Time{}, errors.New("started_at is required with ended_at")
+		}
+		if !body.EndedAt.After(*body.StartedAt) {
+			return time.Time{}, time.Time{}, errors.New("ended_at must be after started_at")
+		}
+		return *body.StartedAt, *body.EndedAt, nil
+	case body.DurationMinutes != nil:
+		if *body.DurationMinutes <= 0 {
+			return time.Time{}, time.Time{}, errors.New("duration_minutes must be > 0")
+		}
+		d := time.Duration(*body.DurationMinutes) * time.Minute
+		if body.StartedAt != nil {
+			return *body.StartedAt, body.StartedAt.Add(d), nil
+		}
+		now := time.Now()
+		return now.Add(-d), now, nil
+	}
+	return time.Time{}, time.Time{}, errors.New("ended_at or duration_minutes is required")
+}
+
+// loggedMinutes rounds a logged duration to the minute, as estimates are
+func loggedMinutes(seconds int64) int64 {
+	return (seconds + 30) / 60
+}
+
+// consumeRemaining lowers the remaining estimate of a task by the logged
+// minutes; negative minutes (entry shortened or deleted) give time back
+func consumeRemaining(taskID uint, minutes int64) {
+	if minutes == 0 {
+		return
+	}
+	initializers.DB.Model(&models.Task{}).
+		Where("id = ? AND remaining_estimate IS NOT NULL", taskID).
+		Updates(map[string]interface{}{
+			"remaining_estimate": gorm.Expr("GREATEST(remaining_estimate - ?, 0)", minutes),
+			"version":            gorm.Expr("version + 1"),
+		})
+}
+
+// GetTaskWorklogs lists the work logs of a task
+func GetTaskWorklogs(c *gin.Context) {
+	task, _, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	var logs []models.WorkLog
+	if err := initializers.DB.Preload("User").
+		Where("task_id = ?", task.ID).
+		Order("started_at, id").
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load work logs"})
+		return
+	}
+	var total int64
+	for _, l := range logs {
+		total += l.DurationSeconds
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"worklogs":                   logs,
+		"total_seconds":              total,
+		"original_estimate_minutes":  task.OriginalEstimate,
+		"remaining_estimate_minutes": task.RemainingEstimate,
+	})
+}
+
+// CreateWorklog logs time on a task for the caller
+func CreateWorklog(c *gin.Context) {
+	task, userID, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	var body worklogPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	started, ended, err := worklogTimes(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry := models.WorkLog{
+		TaskID:          task.ID,
+		ProjectID:       task.ProjectID,
+		UserID:          userID,
+		StartedAt:       started,
+		EndedAt:         &ended,
+		DurationSeconds: int64(ended.Sub(started).Seconds()),
+	}
+	if body.Note != nil {
+		entry.Note = *body.Note
+	}
+	if err := initializers.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create work log"})
+		return
+	}
+	consumeRemaining(task.ID, loggedMinutes(entry.DurationSeconds))
+
+	c.JSON(http.StatusCreated, gin.H{"worklog": entry})
+}
+
+// loadEditableWorklog : une entrée se modifie par son auteur ou l'owner du projet
+func loadEditableWorklog(c *gin.Context) (models.WorkLog, bool) {
+	var entry models.WorkLog
+	wid64, err := strconv.ParseUint(c.Param("worklogId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid work log id"})
+		return entry, false
+	}
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return entry, false
+	}
+	if err := initializers.DB.First(&entry, uint(wid64)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "work log not found"})
+		return entry, false
+	}
+	if entry.UserID != userID {
+		isOwner, err := IsProjectOwner(entry.ProjectID, userID)
+		if err != nil || !isOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the author or the project owner can change this work log"})
+			return entry, false
+		}
+	}
+	return entry, true
+}
+
+// UpdateWorklog changes the times or the note of a finished entry
+func UpdateWorklog(c *gin.Context) {
+	entry, ok := loadEditableWorklog(c)
+	if !ok {
+		return
+	}
+	if entry.EndedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "timer is still running"})
+		return
+	}
+	var body worklogPayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated := map[string]interface{}{}
+	if body.Note != nil {
+		updated["note"] = *body.Note
+	}
+	if body.StartedAt != nil || body.EndedAt != nil || body.DurationMinutes != nil {
+		// les champs absents gardent leur valeur
+		if body.StartedAt == nil {
+			body.StartedAt = &entry.StartedAt
+		}
+		if body.EndedAt == nil && body.DurationMinutes == nil {
+			minutes := int(entry.DurationSeconds / 60)
+			body.DurationMinutes = &minutes
+		}
+		started, ended, err := worklogTimes(body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updated["started_at"] = started
+		updated["ended_at"] = ended
+		updated["duration_seconds"] = int64(ended.Sub(started).Seconds())
+	}
+	if len(updated) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+	oldSeconds := entry.DurationSeconds
+	if err := initializers.DB.Model(&entry).Updates(updated).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update work log"})
+		return
+	}
+	if seconds, ok := updated["duration_seconds"].(int64); ok {
+		consumeRemaining(entry.TaskID, loggedMinutes(seconds)-loggedMinutes(oldSeconds))
+	}
+	initializers.DB.First(&entry, entry.ID)
+	c.JSON(http.StatusOK, gin.H{"worklog": entry})
+}
+
+// DeleteWorklog deletes an entry (a running timer is cancelled)
+func DeleteWorklog(c *gin.Context) {
+	entry, ok := loadEditableWorklog(c)
+	if !ok {
+		return
+	}
+	// libère l'index unique du timer avant la suppression logique
+	if err := initializers.DB.Model(&entry).Update("running_user_id", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete work log"})
+		return
+	}
+	if err := initializers.DB.Delete(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete work log"})
+		return
+	}
+	// temps rendu au reste à faire (un timer en cours n'a rien consommé)
+	if entry.EndedAt != nil {
+		consumeRemaining(entry.TaskID, -loggedMinutes(entry.DurationSeconds))
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "work log deleted"})
+}
+
+//
+// --------------------------- TIMER ---------------------------
+//
+
+func runningTimer(userID uint) (models.WorkLog, error) {
+	var entry models.WorkLog
+	err := initializers.DB.Where("running_user_id = ?", userID).First(&entry).Error
+	return entry, err
+}
+
+// StartTimer starts a timer on a task; a user has at most one running timer
+func StartTimer(c *gin.Context) {
+	task, userID, ok := loadMemberTask(c)
+	if !ok {
+		return
+	}
+	if current, err := runningTimer(userID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "a timer is already running", "timer": current})
+		return
+	}
+
+	entry := models.WorkLog{
+		TaskID:        task.ID,
+		ProjectID:     task.ProjectID,
+		UserID:        userID,
+		StartedAt:     time.Now(),
+		RunningUserID: &userID,
+	}
+	if err := initializers.DB.Create(&entry).Error; err != nil {
+		// index unique : un autre démarrage a gagné
+		if current, err := runningTimer(userID); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "a timer is already running", "timer": current})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start timer"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"timer": entry})
+}
+
+type stopTimerPayload struct {
+	Note string `json:"note"`
+}
+
+// StopTimer stops the caller's running timer and turns it into a work log
+func StopTimer(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	var body stopTimerPayload
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	entry, err := runningTimer(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no running timer"})
+		return
+	}
+	ended := time.Now()
+	seconds := int64(ended.Sub(entry.StartedAt).Seconds())
+	res := initializers.DB.Model(&models.WorkLog{}).
+		Where("id = ? AND running_user_id IS NOT NULL", entry.ID).
+		Updates(map[string]interface{}{
+			"ended_at":         ended,
+			"duration_seconds": seconds,
+			"note":             body.Note,
+			"running_user_id":  nil,
+		})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not stop timer"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no running timer"})
+		return
+	}
+	consumeRemaining(entry.TaskID, loggedMinutes(seconds))
+
+	initializers.DB.First(&entry, entry.ID)
+	c.JSON(http.StatusOK, gin.H{"worklog": entry})
+}
+
+// GetRunningTimer returns the caller's running timer, or null
+func GetRunningTimer(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	entry, err := runningTimer(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusOK, gin.H{"timer": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"timer": entry, "elapsed_seconds": int64(time.Since(entry.StartedAt).Seconds())})
+}
+
+//
+// --------------------------- REPORTS ---------------------------
+//
+
+// reportRange reads from/to (YYYY-MM-DD, both included); default: last 30 days
+func reportRange(c *gin.Context) (time.Time, time.Time, bool) {
+	today := truncateDay(time.Now())
+	from, to := today.AddDate(0, 0, -defaultReportDays+1), today
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.ParseInLocation(filterDateLayout, v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.ParseInLocation(filterDateLayout, v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to is before from"})
+		return from, to, false
+	}
+	return from, to.AddDate(0, 0, 1), true
+}
+
+type timeTotal struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Seconds int64  `json:"seconds"`
+}
+
+// respondTimeReport sends the finished entries of q, as JSON with totals
+// per user and per task, or as CSV with ?format=csv
+func respondTimeReport(c *gin.Context, q *gorm.DB, filename string) {
+	from, to, ok := reportRange(c)
+	if !ok {
+		return
+	}
+	var logs []models.WorkLog
+	if err := q.Preload("User").
+		Where("ended_at IS NOT NULL AND started_at >= ? AND started_at < ?", from, to).
+		Order("started_at, id").
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load work logs"})
+		return
+	}
+
+	taskIDs := []uint{}
+	projectIDs := []uint{}
+	for _, l := range logs {
+		taskIDs = append(taskIDs, l.TaskID)
+		projectIDs = append(projectIDs, l.ProjectID)
+	}
+	titles := map[uint]string{}
+	projects := map[uint]string{}
+	if len(logs) > 0 {
+		var tasks []models.Task
+		initializers.DB.Unscoped().Select("id", "title").Where("id IN ?", taskIDs).Find(&tasks)
+		for _, t := range tasks {
+			titles[t.ID] = t.Title
+		}
+		var ps []models.Project
+		initializers.DB.Unscoped().Select("id", "name").Where("id IN ?", projectIDs).Find(&ps)
+		for _, p := range ps {
+			projects[p.ID] = p.Name
+		}
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.csv"`, filename, from.Format(filterDateLayout)))
+		// même écriture que l'export des tâches (échappement des formules)
+		w, _ := export.New(export.CSV, c.Writer)
+		if err := w.WriteHeader([]string{"date", "project_id", "project", "task_id", "task", "user_id", "user", "started_at", "ended_at", "minutes", "hours", "note"}); err != nil {
+			log.Printf("time report aborted: %v", err)
+			return
+		}
+		for _, l := range logs {
+			if err := w.WriteRow([]interface{}{
+				l.StartedAt.In(time.Local).Format(filterDateLayout),
+				l.ProjectID, projects[l.ProjectID],
+				l.TaskID, titles[l.TaskID],
+				l.UserID, l.User.Name,
+				l.StartedAt.UTC(), l.EndedAt.UTC(),
+				l.DurationSeconds / 60,
+				strconv.FormatFloat(float64(l.DurationSeconds)/3600, 'f', 2, 64),
+				l.Note,
+			}); err != nil {
+				log.Printf("time report aborted: %v", err)
+				return
+			}
+		}
+		if err := w.Close(); err != nil {
+			log.Printf("time report aborted: %v", err)
+		}
+		return
+	}
+
+	var total int64
+	byUser := []*timeTotal{}
+	byTask := []*timeTotal{}
+	users := map[uint]*timeTotal{}
+	tasks := map[uint]*timeTotal{}
+	for _, l := range logs {
+		total += l.DurationSeconds
+		if users[l.UserID] == nil {
+			users[l.UserID] = &timeTotal{ID: l.UserID, Name: l.User.Name}
+			byUser = append(byUser, users[l.UserID])
+		}
+		users[l.UserID].Seconds += l.DurationSeconds
+		if tasks[l.TaskID] == nil {
+			tasks[l.TaskID] = &timeTotal{ID: l.TaskID, Name: titles[l.TaskID]}
+			byTask = append(byTask, tasks[l.TaskID])
+		}
+		tasks[l.TaskID].Seconds += l.DurationSeconds
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":          from.Format(filterDateLayout),
+		"to":            to.AddDate(0, 0, -1).Format(filterDateLayout),
+		"total_seconds": total,
+		"by_user":       byUser,
+		"by_task":       byTask,
+		"worklogs":      logs,
+	})
+}
+
+// GetProjectTimeReport : temps passé sur un projet (membres). Query: from, to, user_id, format=csv
+func GetProjectTimeReport(c *gin.Context) {
+	projectID, ok := requireProjectMemberParam(c)
+	if !ok {
+		return
+	}
+	q := initializers.DB.Where("project_id = ?", projectID)
+	if v := c.Query("user_id"); v != "" {
+		uid, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		q = q.Where("user_id = ?", uint(uid))
+	}
+	respondTimeReport(c, q, fmt.Sprintf("project-%d-time", projectID))
+}
+
+// GetMyTimeReport : temps passé par l'utilisateur courant, tous projets confondus
+func GetMyTimeReport(c *gin.Context) {
+	userID, ok := getUserIDFromCtx(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	respondTimeReport(c, initializers.DB.Where("user_id = ?", userID), "my-time")
+}
@@ -0,0 +1,201 @@
+// Package export writes tables row by row as CSV, JSON or XLSX, without
+// keeping the rows in memory.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	CSV  = "csv"
+	JSON = "json"
+	XLSX = "xlsx"
+)
+
+// Writer receives the header once, then the rows. Values are strings,
+// numbers, bools, time.Time or nil; pointers to those are dereferenced.
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// ContentTypes per format
+var ContentTypes = map[string]string{
+	CSV:  "text/csv; charset=utf-8",
+	JSON: "application/json; charset=utf-8",
+	XLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// New returns a writer for format (csv, json or xlsx)
+func New(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case JSON:
+		return &jsonWriter{w: w}, nil
+	case XLSX:
+		return newXLSXWriter(w), nil
+	}
+	return nil, errors.New("format must be csv, json or xlsx")
+}
+
+// deref unwraps the pointer types used by the models
+func deref(v interface{}) interface{} {
+	switch x := v.(type) {
+	case *string:
+		if x != nil {
+			return *x
+		}
+	case *int:
+		if x != nil {
+			return *x
+		}
+	case *uint:
+		if x != nil {
+			return *x
+		}
+	case *float64:
+		if x != nil {
+			return *x
+		}
+	case *time.Time:
+		if x != nil {
+			return *x
+		}
+	default:
+		return v
+	}
+	return nil
+}
+
+// text formats a value for the text formats (CSV, XLSX strings)
+func text(v interface{}) string {
+	switch x := deref(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(x)
+	case int:
+		return strconv.Itoa(x)
+	case uint:
+		return strconv.FormatUint(uint64(x), 10)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+// formulaLike tells whether a spreadsheet would evaluate a CSV field
+func formulaLike(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch s[0] {
+	case '=', '+', '@', '\t', '\r':
+		return true
+	case '-':
+		// -5 ou -(…) : formule ; "- item" d'une liste markdown : texte
+		return len(s) > 1 && (s[1] == '(' || (s[1] >= '0' && s[1] <= '9'))
+	}
+	return false
+}
+
+// csvText is text for a CSV field: a string that looks like a formula is
+// prefixed with ' so that spreadsheets do not evaluate it (CSV injection)
+func csvText(v interface{}) string {
+	t := text(v)
+	if _, ok := deref(v).(string); ok && formulaLike(t) {
+		return "'" + t
+	}
+	return t
+}
+
+// UnescapeCSV removes the ' that csvText adds, for our own CSV read back
+func UnescapeCSV(s string) string {
+	if len(s) > 1 && s[0] == '\'' && formulaLike(s[1:]) {
+		return s[1:]
+	}
+	return s
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = csvText(v)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	// on vide le tampon régulièrement pour que le client reçoive au fil de l'eau
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonWriter streams an array of objects keyed by column
+type jsonWriter struct {
+	w       io.Writer
+	columns []string
+	rows    int
+}
+
+func (j *jsonWriter) WriteHeader(columns []string) error {
+	j.columns = columns
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonWriter) WriteRow(values []interface{}) error {
+	buf := []byte{'{'}
+	if j.rows > 0 {
+		buf = []byte(",\n{")
+	}
+	for i, v := range values {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, _ := json.Marshal(j.columns[i])
+		val, err := json.Marshal(deref(v))
+		if err != nil {
+			return err
+		}
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		buf = append(buf, val...)
+	}
+	buf = append(buf, '}')
+	j.rows++
+	_, err := j.w.Write(buf)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
@@ -60,12 +60,12 @@ window.addEventListener("DOMContentLoaded", () => {
         } else if (ev.target.matches(".btnEditTask")) {
             const pid = el("detailCard").dataset.projectId;
             const taskId = ev.target.dataset.taskid;
-            await promptEditTask(pid, taskId);
+            await promptEditTask(pid, taskId, ev.target.dataset.version);
         } else if (ev.target.matches(".btnDelTask")) {
             const pid = el("detailCard").dataset.projectId;
             const taskId = ev.target.dataset.taskid;
             if (!confirm("Delete task " + taskId + " ?")) return;
-            await deleteTask(taskId);
+            await deleteTask(taskId, ev.target.dataset.version);
             await openDetail(pid, true);
         } else if (ev.target.matches("#btnBulkAssign")) {
             const pid = el("detailCard").dataset.projectId;
@@ -24,6 +24,7 @@ export async function loadProjects() {
         <div>
           <b>${escapeHtml(p.name)}</b>
           <div class="small muted">ID: ${p.id} — owner_id: ${p.owner_id}</div>
+          <div class="small muted">${p.summary?.tasks ?? 0} tasks — ${p.summary?.overdue ?? 0} overdue — ${p.summary?.my_open_tasks ?? 0} mine — ${p.summary?.members ?? 0} members</div>
         </div>
         <div class="controls">
           <button data-id="${p.id}" class="btnView">View</button>
@@ -91,8 +92,8 @@ export async function openDetail(projectId, forceRefresh = false) {
         <div style="display:flex; flex-direction:column; gap:6px;">
           <button class="btnAssign" data-taskid="${t.id}" data-projectid="${p.id}">Assign</button>
           <button class="btnUnassign" data-taskid="${t.id}">Unassign</button>
-          <button class="btnEditTask" data-taskid="${t.id}">Edit</button>
-          <button class="btnDelTask warn" data-taskid="${t.id}">Delete</button>
+          <button class="btnEditTask" data-taskid="${t.id}" data-version="${t.version}">Edit</button>
+          <button class="btnDelTask warn" data-taskid="${t.id}" data-version="${t.version}">Delete</button>
         </div>
       </div>
     `;
@@ -62,7 +62,7 @@ export async function unassignUserFromTask(taskId, userId) {
     alert("Unassigned");
 }
 
-export async function promptEditTask(projectId, taskId) {
+export async function promptEditTask(projectId, taskId, version) {
     const title = prompt("New title (leave blank = unchanged):");
     const description = prompt("New description (leave blank = unchanged):");
     const status = prompt("New status (TODO / IN_PROGRESS / DONE), leave blank = unchanged:");
@@ -75,8 +75,14 @@ export async function promptEditTask(projectId, taskId) {
     if (Object.keys(body).length === 0) return;
     const r = await apiFetch(`/api/tasks/${taskId}`, {
         method: "PUT",
+        headers: { "If-Match": `"${version}"` },
         body: JSON.stringify(body)
     });
+    if (r.status === 412) {
+        alert("Task was changed by someone else, reloading.");
+        await openDetail(projectId, true);
+        return;
+    }
     if (!r.ok) {
         alert("Update failed: " + (r.json?.error || r.status));
         return;
@@ -85,8 +91,15 @@ export async function promptEditTask(projectId, taskId) {
     await openDetail(projectId, true);
 }
 
-export async function deleteTask(taskId) {
-    const r = await apiFetch(`/api/tasks/${taskId}`, { method: "DELETE" });
+export async function deleteTask(taskId, version) {
+    const r = await apiFetch(`/api/tasks/${taskId}`, {
+        method: "DELETE",
+        headers: { "If-Match": `"${version}"` }
+    });
+    if (r.status === 412) {
+        alert("Task was changed by someone else, not deleted.");
+        return;
+    }
     if (!r.ok) {
         alert("Delete failed: " + (r.json?.error || r.status));
         return;
@@ -0,0 +1,86 @@
+// Package ical writes iCalendar (RFC 5545) feeds: text escaping, line folding
+// at 75 octets and UTC date-times.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const lineLimit = 75
+
+// Writer writes content lines with CRLF endings and folding
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Prop writes NAME:value, value being already formatted
+func (w *Writer) Prop(name, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text writes a TEXT property, escaping \ ; , and newlines
+func (w *Writer) Text(name, value string) {
+	w.Prop(name, EscapeText(value))
+}
+
+// Time writes a UTC DATE-TIME property
+func (w *Writer) Time(name string, t time.Time) {
+	w.Prop(name, FormatTime(t))
+}
+
+func (w *Writer) Begin(component string) { w.Prop("BEGIN", component) }
+func (w *Writer) End(component string)   { w.Prop("END", component) }
+
+// line folds a content line: continuation lines start with a space and no
+// line is longer than 75 octets, without splitting UTF-8 sequences
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+	limit := lineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = lineLimit - 1
+	}
+	w.write(s + "\r\n")
+}
+
+func (w *Writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+// Flush writes the buffered lines and returns the first error
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// EscapeText escapes a TEXT value
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// FormatTime formats t as a UTC DATE-TIME (20060102T150405Z)
+func FormatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,334 @@
+// Package importer reads task lists from other tools into rows the
+// controllers can validate: CSV with a column mapping, the JSON of our own
+// export, Trello board exports and Jira CSV exports.
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/export"
+)
+
+const (
+	CSV    = "csv"
+	JSON   = "json"
+	Trello = "trello"
+	Jira   = "jira"
+
+	// MaxRows bounds the size of one import
+	MaxRows = 10000
+	// MaxTitleLength is the size of the title column of tasks
+	MaxTitleLength = 255
+)
+
+// Fields are the task fields a CSV mapping can target
+var Fields = []string{"title", "description", "status", "priority", "due_date", "assignees", "estimate", "original_estimate_minutes"}
+
+// Row is one task to import. Status and priority are normalized to the
+// model values; assignees are emails or names, resolved by the caller.
+type Row struct {
+	Line             int        `json:"line"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description,omitempty"`
+	Status           string     `json:"status"`
+	Priority         string     `json:"priority"`
+	DueDate          *time.Time `json:"due_date,omitempty"`
+	Assignees        []string   `json:"assignees,omitempty"`
+	Estimate         string     `json:"estimate,omitempty"`
+	OriginalEstimate *int       `json:"original_estimate_minutes,omitempty"`
+}
+
+// Issue is a problem found on a line (0 = whole file)
+type Issue struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result : lignes lues, erreurs bloquantes et avertissements
+type Result struct {
+	Rows     []Row
+	Errors   []Issue
+	Warnings []Issue
+	Skipped  int
+}
+
+func (r *Result) errorf(line int, field, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, Issue{Line: line, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Result) warnf(line int, field, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, Issue{Line: line, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Parse reads a file of the given format. mapping maps a field of Fields to
+// a column name (CSV and JSON only); missing fields use their own name.
+func Parse(format string, r io.Reader, mapping map[string]string) (*Result, error) {
+	for field := range mapping {
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown field %q in mapping", field)
+		}
+	}
+	res := &Result{}
+	var err error
+	switch format {
+	case CSV:
+		err = parseCSV(r, withDefaults(mapping, ourColumns), res)
+	case JSON:
+		err = parseJSON(r, withDefaults(mapping, ourColumns), res)
+	case Trello:
+		err = parseTrello(r, res)
+	case Jira:
+		err = parseCSV(r, withDefaults(mapping, jiraColumns), res)
+	default:
+		return nil, errors.New("format must be csv, json, trello or jira")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) > MaxRows {
+		return nil, fmt.Errorf("too many tasks (max %d)", MaxRows)
+	}
+	return res, nil
+}
+
+func isField(name string) bool {
+	for _, f := range Fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// colonnes par défaut : celles de notre export, et celles de Jira
+var (
+	ourColumns = map[string]string{
+		"title": "title", "description": "description", "status": "status", "priority": "priority",
+		"due_date": "due_date", "assignees": "assignee_emails", "estimate": "estimate",
+		"original_estimate_minutes": "original_estimate_minutes",
+		"assignee_names":            "assignees", // si assignee_emails est absent
+	}
+	jiraColumns = map[string]string{
+		"title": "summary", "description": "description", "status": "status", "priority": "priority",
+		"due_date": "due date", "assignees": "assignee", "estimate": "custom field (story points)",
+		"original_estimate_seconds": "original estimate",
+	}
+)
+
+func withDefaults(mapping, defaults map[string]string) map[string]string {
+	out := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range mapping {
+		out[k] = strings.ToLower(strings.TrimSpace(v))
+	}
+	return out
+}
+
+func parseCSV(r io.Reader, mapping map[string]string, res *Result) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return errors.New("empty file")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV: %v", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+	if !hasColumn(header, mapping["title"]) {
+		return fmt.Errorf("missing column %q for title", mapping["title"])
+	}
+
+	line := 1
+	for {
+		values, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("invalid CSV at line %d: %v", line, err)
+		}
+		if len(res.Rows) >= MaxRows {
+			return fmt.Errorf("too many tasks (max %d)", MaxRows)
+		}
+		// Jira répète certaines colonnes : on garde la première valeur non vide
+		rec := map[string]string{}
+		for i, v := range values {
+			if i < len(header) && rec[header[i]] == "" {
+				rec[header[i]] = strings.TrimSpace(export.UnescapeCSV(v))
+			}
+		}
+		fromRecord(line, rec, mapping, res)
+	}
+	return nil
+}
+
+func hasColumn(header []string, name string) bool {
+	for _, h := range header {
+		if h == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseJSON reads an array of objects, e.g. our own JSON export
+func parseJSON(r io.Reader, mapping map[string]string, res *Result) error {
+	var items []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	if len(items) > MaxRows {
+		return fmt.Errorf("too many tasks (max %d)", MaxRows)
+	}
+	for i, item := range items {
+		rec := map[string]string{}
+		for k, v := range item {
+			rec[strings.ToLower(k)] = jsonText(v)
+		}
+		fromRecord(i+1, rec, mapping, res)
+	}
+	return nil
+}
+
+func jsonText(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case []interface{}:
+		parts := make([]string, 0, len(x))
+		for _, p := range x {
+			parts = append(parts, jsonText(p))
+		}
+		return strings.Join(parts, ";")
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+// fromRecord builds a row from a record keyed by lower-case column names
+func fromRecord(line int, rec map[string]string, mapping map[string]string, res *Result) {
+	get := func(field string) string { return rec[mapping[field]] }
+
+	row := Row{Line: line, Title: get("title"), Description: get("description")}
+	if row.Title == "" {
+		res.errorf(line, "title", "title is required")
+		return
+	}
+	if utf8.RuneCountInString(row.Title) > MaxTitleLength {
+		res.errorf(line, "title", "title is longer than %d characters", MaxTitleLength)
+		return
+	}
+	row.Status = normalizeStatus(line, get("status"), res)
+	row.Priority = normalizePriority(line, get("priority"), res)
+
+	if v := get("due_date"); v != "" {
+		due, err := parseDate(v)
+		if err != nil {
+			res.errorf(line, "due_date", "invalid date %q", v)
+			return
+		}
+		row.DueDate = &due
+	}
+
+	assignees := get("assignees")
+	if assignees == "" && mapping["assignee_names"] != "" {
+		assignees = get("assignee_names")
+	}
+	row.Assignees = splitList(assignees)
+	row.Estimate = get("estimate")
+
+	if v := get("original_estimate_minutes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			res.errorf(line, "original_estimate_minutes", "invalid number %q", v)
+			return
+		}
+		row.OriginalEstimate = &n
+	} else if v := get("original_estimate_seconds"); v != "" && mapping["original_estimate_seconds"] != "" {
+		// Jira exporte les estimations en secondes
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			n := secs / 60
+			row.OriginalEstimate = &n
+		}
+	}
+	res.Rows = append(res.Rows, row)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// normalizeStatus maps the usual column names of other tools to our statuses
+func normalizeStatus(line int, v string, res *Result) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "todo", "to do", "open", "backlog", "new", "selected for development", "à faire", "a faire":
+		return "TODO"
+	case "doing", "in progress", "in review", "review", "en cours":
+		return "DOING"
+	case "done", "closed", "resolved", "complete", "completed", "terminé", "termine":
+		return "DONE"
+	}
+	res.warnf(line, "status", "unknown status %q, imported as TODO", v)
+	return "TODO"
+}
+
+func normalizePriority(line int, v string, res *Result) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "medium", "normal", "moyenne":
+		return "MEDIUM"
+	case "high", "highest", "critical", "blocker", "urgent", "haute":
+		return "HIGH"
+	case "low", "lowest", "minor", "trivial", "basse":
+		return "LOW"
+	}
+	res.warnf(line, "priority", "unknown priority %q, imported as MEDIUM", v)
+	return "MEDIUM"
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"02/Jan/06 3:04 PM", // Jira
+	"2/Jan/06 3:04 PM",
+	"02/Jan/06",
+	"02/01/2006",
+}
+
+func parseDate(v string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown date format")
+}
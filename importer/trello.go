@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// trelloBoard is the part of a Trello board export (Menu → Print and export → JSON) we use
+type trelloBoard struct {
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		Name        string     `json:"name"`
+		Desc        string     `json:"desc"`
+		IDList      string     `json:"idList"`
+		Due         *time.Time `json:"due"`
+		DueComplete bool       `json:"dueComplete"`
+		Closed      bool       `json:"closed"`
+		IDMembers   []string   `json:"idMembers"`
+		Labels      []struct {
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Members []struct {
+		ID       string `json:"id"`
+		FullName string `json:"fullName"`
+		Username string `json:"username"`
+	} `json:"members"`
+}
+
+// parseTrello turns the open cards of a board into rows. The list name gives
+// the status; members are matched by full name since exports carry no email.
+func parseTrello(r io.Reader, res *Result) error {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return fmt.Errorf("invalid Trello JSON: %v", err)
+	}
+	if len(board.Cards) > MaxRows {
+		return fmt.Errorf("too many tasks (max %d)", MaxRows)
+	}
+
+	lists := map[string]string{}
+	closedLists := map[string]bool{}
+	for _, l := range board.Lists {
+		lists[l.ID] = l.Name
+		closedLists[l.ID] = l.Closed
+	}
+	members := map[string]string{}
+	for _, m := range board.Members {
+		members[m.ID] = m.FullName
+		if m.FullName == "" {
+			members[m.ID] = m.Username
+		}
+	}
+
+	for i, card := range board.Cards {
+		line := i + 1
+		if card.Closed || closedLists[card.IDList] {
+			res.Skipped++
+			continue
+		}
+		title := strings.TrimSpace(card.Name)
+		if title == "" {
+			res.errorf(line, "title", "card without name")
+			continue
+		}
+		if utf8.RuneCountInString(title) > MaxTitleLength {
+			res.errorf(line, "title", "card name is longer than %d characters", MaxTitleLength)
+			continue
+		}
+		row := Row{
+			Line:        line,
+			Title:       title,
+			Description: card.Desc,
+			Status:      trelloStatus(lists[card.IDList]),
+			Priority:    "MEDIUM",
+			DueDate:     card.Due,
+		}
+		if card.DueComplete {
+			row.Status = "DONE"
+		}
+		// étiquette rouge = priorité haute, c'est l'usage courant sur Trello
+		for _, l := range card.Labels {
+			if l.Color == "red" {
+				row.Priority = "HIGH"
+			}
+		}
+		for _, id := range card.IDMembers {
+			if name := members[id]; name != "" {
+				row.Assignees = append(row.Assignees, name)
+			}
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	return nil
+}
+
+// trelloStatus guesses the status from the list name
+func trelloStatus(list string) string {
+	name := strings.ToLower(list)
+	for _, w := range []string{"done", "complete", "termin", "fini", "closed"} {
+		if strings.Contains(name, w) {
+			return "DONE"
+		}
+	}
+	for _, w := range []string{"doing", "progress", "en cours", "review", "wip"} {
+		if strings.Contains(name, w) {
+			return "DOING"
+		}
+	}
+	return "TODO"
+}
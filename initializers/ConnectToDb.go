@@ -3,7 +3,9 @@ package initializers
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -13,13 +15,24 @@ var DB *gorm.DB
 
 func ConnectToDB() {
 
+	// fuseau des DATETIME stockés (DB_TIMEZONE, heure locale du serveur par défaut
+	// comme avant) ; "UTC" est à choisir pour une nouvelle base.
+	tz := os.Getenv("DB_TIMEZONE")
+	if tz == "" {
+		tz = "Local"
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		log.Fatalf("Invalid DB_TIMEZONE %q: %v", tz, err)
+	}
+
 	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=%s",
 		os.Getenv("DB_USER"),
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_PORT"),
 		os.Getenv("DB_NAME"),
+		url.QueryEscape(tz),
 	)
 
 	var err error
@@ -11,15 +11,48 @@
 			fmt.Println("SyncDataBase: DB is nil")
 			return
 		}
+		// les tâches existantes n'ont pas encore de watchers
+		backfillWatchers := !DB.Migrator().HasTable(&models.TaskWatcher{})
+
 		if err := DB.AutoMigrate(
 			&models.User{},
 			&models.Project{},
+			&models.Sprint{},
 			&models.ProjectMember{},
 			&models.Task{},
 			&models.TaskAssignee{},
+			&models.TaskWatcher{},
+			&models.WorkLog{},
+			&models.SavedView{},
+			&models.WipLimit{},
+			&models.TaskActivity{},
+			&models.Webhook{},
+			&models.WebhookDelivery{},
+			&models.Notification{},
+			&models.NotificationMute{},
+			&models.EmailPreference{},
+			&models.EmailQueueItem{},
+			&models.SchedulerLock{},
+			&models.ImportJob{},
+			&models.CalendarFeed{},
+			&models.ProjectTemplate{},
+			&models.TemplateTask{},
+			&models.TemplateWipLimit{},
+			&models.TemplateMember{},
 		); err != nil {
 			fmt.Println("AutoMigrate error:", err)
 		} else {
 			fmt.Println("AutoMigrate completed")
+			if backfillWatchers {
+				// créateur + assignees de chaque tâche
+				if err := DB.Exec(`INSERT IGNORE INTO task_watchers (task_id, user_id, created_at)
+					SELECT id, creator_id, NOW() FROM tasks WHERE deleted_at IS NULL`).Error; err != nil {
+					fmt.Println("Watchers backfill error:", err)
+				}
+				if err := DB.Exec(`INSERT IGNORE INTO task_watchers (task_id, user_id, created_at)
+					SELECT task_id, user_id, NOW() FROM task_assignees WHERE deleted_at IS NULL`).Error; err != nil {
+					fmt.Println("Watchers backfill error:", err)
+				}
+			}
 		}
 	}
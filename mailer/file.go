@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMailer writes every message as an .eml file in Dir
+type FileMailer struct {
+	Dir  string
+	From string
+
+	mu sync.Mutex
+	n  int
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	data, err := build(m.From, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.n++
+	n := m.n
+	m.mu.Unlock()
+
+	safeTo := strings.NewReplacer("@", "_at_", "<", "", ">", "", " ", "_", "/", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%04d-%s.eml", time.Now().Format("20060102-150405"), n, safeTo)
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0o644)
+}
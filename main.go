@@ -10,7 +10,12 @@ import (
 
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/controllers"
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/mailer"
 	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/middleware"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/notifications"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/reminders"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/webhooks"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -29,7 +34,9 @@ func main() {
 	}
 
 	gin.SetMode(gin.DebugMode)
-	router := gin.Default()
+	// gin.Default() sans son logger, qui écrirait le ?access_token= des flux SSE
+	router := gin.New()
+	router.Use(middleware.Logger(), gin.Recovery())
 
 	// --------------------- CORS (dev-friendly) ---------------------
 	router.Use(cors.New(cors.Config{
@@ -40,8 +47,8 @@ func main() {
 				true
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -93,18 +100,80 @@ func main() {
 		api.POST("/projects", middleware.RequireAuth(), controllers.CreateProject) //marche 
 		api.GET("/projects", middleware.RequireAuth(), controllers.GetMyProjects) //marche
 		api.GET("/projects/:projectId", middleware.RequireAuth(), controllers.GetProjectDetail) //marche
+		api.GET("/projects/:projectId/summary", middleware.RequireAuth(), controllers.GetProjectSummary)
+		api.POST("/projects/:projectId/clone", middleware.RequireAuth(), controllers.CloneProject)
+		api.POST("/projects/:projectId/templates", middleware.RequireAuth(), controllers.CreateProjectTemplate)
+		api.GET("/projects/:projectId/export", middleware.RequireAuth(), controllers.ExportProjectTasks)
+		api.POST("/projects/:projectId/import", middleware.RequireAuth(), controllers.ImportProjectTasks)
+		api.GET("/projects/:projectId/imports/:importId", middleware.RequireAuth(), controllers.GetImportJob)
+		api.PUT("/projects/:projectId", middleware.RequireAuth(), controllers.UpdateProject)
 		api.DELETE("/projects/:projectId", middleware.RequireAuth(), controllers.DeleteProject) 
+		api.GET("/templates", middleware.RequireAuth(), controllers.GetProjectTemplates)
+		api.GET("/templates/:templateId", middleware.RequireAuth(), controllers.GetProjectTemplate)
+		api.DELETE("/templates/:templateId", middleware.RequireAuth(), controllers.DeleteProjectTemplate)
+		api.POST("/templates/:templateId/projects", middleware.RequireAuth(), controllers.CreateProjectFromTemplate)
+		api.GET("/projects/:projectId/wip-limits", middleware.RequireAuth(), controllers.GetWipLimits)
+		api.PUT("/projects/:projectId/wip-limits", middleware.RequireAuth(), controllers.SetWipLimits)
+		api.GET("/projects/:projectId/activity", middleware.RequireAuth(), controllers.GetProjectActivity)
+		api.GET("/projects/:projectId/events", middleware.RequireAuth(), controllers.ProjectEvents)
+
+		// Sprints / milestones
+		api.GET("/projects/:projectId/sprints", middleware.RequireAuth(), controllers.GetSprints)
+		api.POST("/projects/:projectId/sprints", middleware.RequireAuth(), controllers.CreateSprint)
+		api.GET("/projects/:projectId/sprints/:sprintId", middleware.RequireAuth(), controllers.GetSprint)
+		api.PUT("/projects/:projectId/sprints/:sprintId", middleware.RequireAuth(), controllers.UpdateSprint)
+		api.DELETE("/projects/:projectId/sprints/:sprintId", middleware.RequireAuth(), controllers.DeleteSprint)
+		api.POST("/projects/:projectId/sprints/:sprintId/start", middleware.RequireAuth(), controllers.StartSprint)
+		api.POST("/projects/:projectId/sprints/:sprintId/close", middleware.RequireAuth(), controllers.CloseSprint)
+		api.GET("/projects/:projectId/velocity", middleware.RequireAuth(), controllers.GetProjectVelocity)
+		api.GET("/projects/:projectId/analytics/burndown", middleware.RequireAuth(), controllers.GetProjectBurndown)
+		api.GET("/projects/:projectId/analytics/burnup", middleware.RequireAuth(), controllers.GetProjectBurnup)
+		api.GET("/projects/:projectId/analytics/cfd", middleware.RequireAuth(), controllers.GetProjectCFD)
+		api.GET("/projects/:projectId/analytics/cycle-time", middleware.RequireAuth(), controllers.GetProjectCycleTime)
+
+		// Webhooks (owner)
+		api.GET("/projects/:projectId/webhooks", middleware.RequireAuth(), controllers.GetWebhooks)
+		api.POST("/projects/:projectId/webhooks", middleware.RequireAuth(), controllers.CreateWebhook)
+		api.PUT("/projects/:projectId/webhooks/:webhookId", middleware.RequireAuth(), controllers.UpdateWebhook)
+		api.DELETE("/projects/:projectId/webhooks/:webhookId", middleware.RequireAuth(), controllers.DeleteWebhook)
+		api.POST("/projects/:projectId/webhooks/:webhookId/rotate-secret", middleware.RequireAuth(), controllers.RotateWebhookSecret)
+		api.POST("/projects/:projectId/webhooks/:webhookId/ping", middleware.RequireAuth(), controllers.PingWebhook)
+		api.GET("/projects/:projectId/webhooks/:webhookId/deliveries", middleware.RequireAuth(), controllers.GetWebhookDeliveries)
+		api.POST("/projects/:projectId/webhooks/:webhookId/deliveries/:deliveryId/redeliver", middleware.RequireAuth(), controllers.RedeliverWebhook)
 
 		// Members
 		api.POST("/projects/:projectId/members", middleware.RequireAuth(), controllers.AddMember) //marche
 		api.DELETE("/projects/:projectId/members/:userId", middleware.RequireAuth(), controllers.RemoveMemberByParam) //marche
+		api.PUT("/projects/:projectId/members/:userId/capacity", middleware.RequireAuth(), controllers.SetMemberCapacity)
+		api.GET("/projects/:projectId/workload", middleware.RequireAuth(), controllers.GetProjectWorkload)
+		api.GET("/me/workload", middleware.RequireAuth(), controllers.GetMyWorkload)
 		
 
 		// Tasks
 		api.POST("/projects/:projectId/tasks", middleware.RequireAuth(), controllers.CreateTask) //marche
 		api.GET("/projects/:projectId/tasks", middleware.RequireAuth(), controllers.GetProjectTasks) //marche
+		api.POST("/projects/:projectId/tasks/bulk", middleware.RequireAuth(), controllers.BulkUpdateTasks)
+		api.GET("/tasks/:taskId", middleware.RequireAuth(), controllers.GetTask)
 		api.PUT("/tasks/:taskId", middleware.RequireAuth(), controllers.UpdateTask) //marche 
 		api.DELETE("/tasks/:taskId", middleware.RequireAuth(), controllers.DeleteTask) //marche 
+		api.POST("/tasks/:taskId/move", middleware.RequireAuth(), controllers.MoveTask)
+		api.POST("/tasks/:taskId/transfer", middleware.RequireAuth(), controllers.TransferTask)
+		api.POST("/tasks/:taskId/duplicate", middleware.RequireAuth(), controllers.DuplicateTask)
+		api.GET("/tasks/:taskId/history", middleware.RequireAuth(), controllers.GetTaskHistory)
+		api.GET("/tasks/:taskId/watchers", middleware.RequireAuth(), controllers.GetTaskWatchers)
+		api.POST("/tasks/:taskId/watch", middleware.RequireAuth(), controllers.WatchTask)
+		api.DELETE("/tasks/:taskId/watch", middleware.RequireAuth(), controllers.UnwatchTask)
+
+		// Time tracking
+		api.GET("/tasks/:taskId/worklogs", middleware.RequireAuth(), controllers.GetTaskWorklogs)
+		api.POST("/tasks/:taskId/worklogs", middleware.RequireAuth(), controllers.CreateWorklog)
+		api.PUT("/worklogs/:worklogId", middleware.RequireAuth(), controllers.UpdateWorklog)
+		api.DELETE("/worklogs/:worklogId", middleware.RequireAuth(), controllers.DeleteWorklog)
+		api.POST("/tasks/:taskId/timer/start", middleware.RequireAuth(), controllers.StartTimer)
+		api.POST("/timer/stop", middleware.RequireAuth(), controllers.StopTimer)
+		api.GET("/timer", middleware.RequireAuth(), controllers.GetRunningTimer)
+		api.GET("/projects/:projectId/time-report", middleware.RequireAuth(), controllers.GetProjectTimeReport)
+		api.GET("/me/time-report", middleware.RequireAuth(), controllers.GetMyTimeReport)
 
 
 		// keeping existing PUT route
@@ -116,8 +185,47 @@ func main() {
 		// (optionnel) also accept POST without project if your front may call that
 		api.POST("/tasks/:taskId/assign", middleware.RequireAuth(), controllers.AssignTask) //marche
 
+		// Notifications
+		api.GET("/notifications", middleware.RequireAuth(), controllers.GetNotifications)
+		api.GET("/notifications/unread-count", middleware.RequireAuth(), controllers.GetUnreadCount)
+		api.POST("/notifications/read-all", middleware.RequireAuth(), controllers.MarkAllNotificationsRead)
+		api.POST("/notifications/:notificationId/read", middleware.RequireAuth(), controllers.MarkNotificationRead)
+		api.DELETE("/notifications/:notificationId", middleware.RequireAuth(), controllers.DeleteNotification)
+		api.GET("/notifications/preferences", middleware.RequireAuth(), controllers.GetNotificationPreferences)
+		api.PUT("/notifications/preferences", middleware.RequireAuth(), controllers.SetNotificationPreferences)
+		api.GET("/notifications/email", middleware.RequireAuth(), controllers.GetEmailPreferences)
+		api.PUT("/notifications/email", middleware.RequireAuth(), controllers.SetEmailPreferences)
+
+		// Email unsubscribe (signed link, no auth)
+		api.GET("/email/unsubscribe", controllers.UnsubscribeEmailPage)
+		api.POST("/email/unsubscribe", controllers.UnsubscribeEmail)
+
+		// iCalendar feeds (secret token in the URL, no auth)
+		api.GET("/me/calendar", middleware.RequireAuth(), controllers.GetMyCalendarFeed)
+		api.POST("/me/calendar/rotate", middleware.RequireAuth(), controllers.RotateMyCalendarFeed)
+		api.GET("/projects/:projectId/calendar", middleware.RequireAuth(), controllers.GetProjectCalendarFeed)
+		api.POST("/projects/:projectId/calendar/rotate", middleware.RequireAuth(), controllers.RotateProjectCalendarFeed)
+		api.GET("/calendar/:token", controllers.CalendarFeed)
+
+		// Saved views
+		api.GET("/views", middleware.RequireAuth(), controllers.GetSavedViews)
+		api.POST("/views", middleware.RequireAuth(), controllers.CreateSavedView)
+		api.GET("/views/:viewId", middleware.RequireAuth(), controllers.GetSavedView)
+		api.PUT("/views/:viewId", middleware.RequireAuth(), controllers.UpdateSavedView)
+		api.DELETE("/views/:viewId", middleware.RequireAuth(), controllers.DeleteSavedView)
+		api.GET("/views/:viewId/tasks", middleware.RequireAuth(), controllers.RunSavedView)
+
 	}
 
+	// -------------------- BACKGROUND JOBS --------------------
+	controllers.StartRankRebalancer(time.Hour)
+	controllers.StartRecurrenceScheduler(time.Minute)
+	webhooks.Register(realtime.DefaultHub)
+	controllers.RegisterAnalyticsCache(realtime.DefaultHub)
+	webhooks.StartWorker(5 * time.Second)
+	notifications.StartEmailWorker(mailer.FromEnv(), 30*time.Second)
+	reminders.New(initializers.DB, reminders.SystemClock).Start(time.Minute)
+
 	// -------------------- START SERVER --------------------
 	log.Printf("Starting server on :%s\n", port)
 
@@ -37,6 +37,12 @@ func RequireAuth() gin.HandlerFunc {
 			}
 		}
 
+		// fallback query string, seulement pour les flux SSE :
+		// EventSource (navigateur) ne peut pas envoyer de header Authorization
+		if tokenString == "" && strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			tokenString = c.Query("access_token")
+		}
+
 		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
 			c.Abort()
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CalendarFeed is the secret token of an iCalendar feed: the tasks assigned
+// to a user (ProjectID nil) or the tasks of a project, seen by that user.
+// Rotating the token replaces it, so old subscription URLs stop working.
+type CalendarFeed struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"index;not null" json:"user_id"`
+	ProjectID *uint  `gorm:"index" json:"project_id"`
+	Token     string `gorm:"size:64;uniqueIndex;not null" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
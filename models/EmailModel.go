@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	EmailOff       = "OFF"
+	EmailImmediate = "IMMEDIATE"
+	EmailDigest    = "DIGEST"
+)
+
+// EmailPreference is the email delivery choice of a user. Users without a
+// row get EmailImmediate.
+type EmailPreference struct {
+	ID         uint   `gorm:"primaryKey" json:"-"`
+	UserID     uint   `gorm:"uniqueIndex;not null" json:"user_id"`
+	Mode       string `gorm:"size:10;not null;default:IMMEDIATE" json:"mode"`
+	DigestHour int    `gorm:"not null;default:8" json:"digest_hour"` // 0-23, server time
+
+	LastDigestAt *time.Time `json:"last_digest_at"`
+
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EmailQueueItem is a notification waiting to be emailed.
+// SentAt is set when it was sent (or skipped).
+type EmailQueueItem struct {
+	ID             uint `gorm:"primaryKey"`
+	UserID         uint `gorm:"index;not null"`
+	NotificationID uint `gorm:"not null"`
+	ProjectID      *uint
+	TaskID         *uint
+	Type           string `gorm:"size:30;not null"`
+	Message        string `gorm:"size:500;not null"`
+
+	SentAt *time.Time `gorm:"index"`
+
+	CreatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	ImportRunning = "RUNNING"
+	ImportDone    = "DONE"
+	ImportFailed  = "FAILED" // rien n'a été importé (transaction annulée)
+)
+
+// ImportJob tracks a bulk import of tasks into a project. Rows are created in
+// one transaction; Processed moves forward while it runs.
+type ImportJob struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	ProjectID uint   `gorm:"index;not null" json:"project_id"`
+	UserID    uint   `gorm:"index;not null" json:"user_id"`
+	Format    string `gorm:"size:20;not null" json:"format"`
+	Filename  string `gorm:"size:255" json:"filename"`
+	Status    string `gorm:"size:20;not null" json:"status"`
+	Total     int    `gorm:"not null" json:"total"`
+	Processed int    `gorm:"not null;default:0" json:"processed"`
+	Imported  int    `gorm:"not null;default:0" json:"imported"`
+	Error     string `gorm:"type:text" json:"error,omitempty"`
+
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotifAssigned      = "assigned"
+	NotifMention       = "mention"
+	NotifStatusChanged = "status_changed"
+	NotifTaskUpdated   = "task_updated"
+	NotifDueSoon       = "due_soon"
+	NotifOverdue       = "overdue"
+	NotifEscalated     = "overdue_escalated"
+	NotifMembership    = "membership"
+)
+
+// NotificationTypes lists every type above
+var NotificationTypes = []string{NotifAssigned, NotifMention, NotifStatusChanged, NotifTaskUpdated, NotifDueSoon, NotifOverdue, NotifEscalated, NotifMembership}
+
+type Notification struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"index;not null" json:"user_id"`
+	Type      string `gorm:"size:30;not null" json:"type"`
+	ProjectID *uint  `gorm:"index" json:"project_id"`
+	TaskID    *uint  `gorm:"index" json:"task_id"`
+	ActorID   *uint  `json:"actor_id"`
+	Message   string `gorm:"size:500;not null" json:"message"`
+
+	ReadAt *time.Time `gorm:"index" json:"read_at"`
+
+	CreatedAt time.Time      `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// NotificationMute silences notifications of a user. An empty Type mutes
+// every type, a nil ProjectID every project.
+type NotificationMute struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    uint   `gorm:"index;not null" json:"user_id"`
+	Type      string `gorm:"size:30" json:"type"`
+	ProjectID *uint  `json:"project_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
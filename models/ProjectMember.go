@@ -12,6 +12,9 @@ type ProjectMember struct {
 	UserID    uint           `gorm:"index;not null" json:"user_id"`
 	Role      string         `gorm:"size:20;not null" json:"role"`
 
+	// heures par semaine consacrées au projet, null = non renseigné
+	CapacityHours *float64 `json:"capacity_hours"`
+
 	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
 
 	CreatedAt time.Time      `json:"created_at"`
@@ -9,6 +9,11 @@ import (
 const (
 	RoleOwner  = "OWNER"
 	RoleMember = "MEMBER"
+
+	// échelles d'estimation des tâches
+	EstimateFibonacci = "FIBONACCI"
+	EstimateTShirt    = "TSHIRT"
+	EstimateHours     = "HOURS"
 )
 
 type Project struct {
@@ -19,6 +24,15 @@ type Project struct {
 	OwnerID *uint `gorm:"index" json:"owner_id"`
 	Owner   User  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"owner"`
 
+	// version of the project settings, sent as ETag
+	Version uint `gorm:"not null;default:1" json:"version"`
+
+	// WIP limits enforcement: OFF, WARN or BLOCK
+	WipMode string `gorm:"size:10;default:WARN" json:"wip_mode"`
+
+	// scale of Task.Estimate: FIBONACCI, TSHIRT or HOURS
+	EstimateScale string `gorm:"size:10;default:FIBONACCI" json:"estimate_scale"`
+
 	Members []ProjectMember `gorm:"foreignKey:ProjectID" json:"members"`
 	Tasks   []Task          `gorm:"foreignKey:ProjectID" json:"tasks"`
 
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProjectTemplate is a reusable snapshot of a project: settings, WIP limits,
+// tasks and optionally its members (always re-added as MEMBER). Due dates
+// are kept relative to the start of the project so that they can be shifted
+// on each new project.
+// The template is visible to its owner and to the members of SourceProjectID.
+type ProjectTemplate struct {
+	ID              uint   `gorm:"primaryKey" json:"id"`
+	Name            string `gorm:"size:150;not null" json:"name"`
+	Description     string `gorm:"type:text" json:"description"`
+	OwnerID         uint   `gorm:"index;not null" json:"owner_id"`
+	SourceProjectID *uint  `gorm:"index" json:"source_project_id"`
+
+	ProjectDescription string `gorm:"type:text" json:"project_description"`
+	WipMode            string `gorm:"size:10" json:"wip_mode"`
+	EstimateScale      string `gorm:"size:10" json:"estimate_scale"`
+
+	Tasks     []TemplateTask     `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE;" json:"tasks,omitempty"`
+	WipLimits []TemplateWipLimit `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE;" json:"wip_limits,omitempty"`
+	Members   []TemplateMember   `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE;" json:"members,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TemplateTask is a task of a template; DueOffsetMinutes is counted from
+// the start date of the project (null = no due date)
+type TemplateTask struct {
+	ID         uint `gorm:"primaryKey" json:"id"`
+	TemplateID uint `gorm:"index;not null" json:"template_id"`
+	Position   int  `gorm:"not null" json:"position"`
+
+	Title            string `gorm:"size:255;not null" json:"title"`
+	Description      string `gorm:"type:text" json:"description"`
+	Status           string `gorm:"size:20" json:"status"`
+	Priority         string `gorm:"size:20" json:"priority"`
+	DueOffsetMinutes *int   `json:"due_offset_minutes"`
+	Estimate         string `gorm:"size:10" json:"estimate"`
+	OriginalEstimate *int   `json:"original_estimate_minutes"`
+	Recurrence       string `gorm:"size:255" json:"recurrence,omitempty"`
+	RecurrenceMode   string `gorm:"size:10" json:"recurrence_mode,omitempty"`
+}
+
+// TemplateWipLimit is a WIP limit of a template (AssigneeID as in WipLimit)
+type TemplateWipLimit struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	TemplateID uint   `gorm:"index;not null" json:"template_id"`
+	Status     string `gorm:"size:20;not null" json:"status"`
+	AssigneeID *uint  `json:"assignee_id"`
+	MaxTasks   int    `gorm:"not null" json:"max_tasks"`
+}
+
+// TemplateMember is a default member of the projects created from a template
+type TemplateMember struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	TemplateID uint   `gorm:"index;not null" json:"template_id"`
+	UserID     uint   `gorm:"not null" json:"user_id"`
+	Role       string `gorm:"size:20;not null" json:"role"`
+
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ViewLayoutList     = "list"
+	ViewLayoutBoard    = "board"
+	ViewLayoutCalendar = "calendar"
+)
+
+// SavedView is a named task query (filter + sort + grouping + display options).
+// Without ProjectID the view runs across every project of its owner; with
+// ProjectID and Shared=true every member of that project can use it.
+type SavedView struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Name      string `gorm:"size:150;not null" json:"name"`
+	OwnerID   uint   `gorm:"index;not null" json:"owner_id"`
+	ProjectID *uint  `gorm:"index" json:"project_id"`
+	Shared    bool   `gorm:"not null;default:false" json:"shared"`
+
+	Filter   string `gorm:"type:text" json:"filter"`
+	SortBy   string `gorm:"size:50" json:"sort_by"`
+	SortDesc bool   `gorm:"not null;default:false" json:"sort_desc"`
+	GroupBy  string `gorm:"size:50" json:"group_by"`
+	Columns  string `gorm:"size:255" json:"columns"` // comma separated
+	Layout   string `gorm:"size:20;default:list" json:"layout"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// SchedulerLock is a lease on a background job: only the holder whose
+// lease has not expired runs it, so several server instances can share
+// one database.
+type SchedulerLock struct {
+	Name      string    `gorm:"primaryKey;size:64"`
+	Holder    string    `gorm:"size:128;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SprintPlanned = "PLANNED"
+	SprintActive  = "ACTIVE"
+	SprintClosed  = "CLOSED"
+)
+
+// Sprint (or milestone) groups tasks of a project over a period.
+// A project has at most one ACTIVE sprint; a task is in at most one sprint.
+type Sprint struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	ProjectID uint       `gorm:"index;not null" json:"project_id"`
+	Name      string     `gorm:"size:150;not null" json:"name"`
+	Goal      string     `gorm:"type:text" json:"goal"`
+	StartDate *time.Time `json:"start_date"`
+	EndDate   *time.Time `json:"end_date"`
+	State     string     `gorm:"size:10;not null;default:PLANNED;index" json:"state"`
+
+	StartedAt *time.Time `json:"started_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+
+	// scope when the sprint was started, for velocity reports
+	CommittedPoints *float64 `json:"committed_points"`
+	CommittedTasks  *int     `json:"committed_tasks"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
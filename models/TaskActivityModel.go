@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	ActivityCreated    = "created"
+	ActivityUpdated    = "updated"
+	ActivityAssigned   = "assigned"
+	ActivityUnassigned = "unassigned"
+	ActivityDeleted    = "deleted"
+)
+
+// TaskActivity is one entry of a task's history. Entries are append-only:
+// an update writes one row per changed field.
+type TaskActivity struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	TaskID    uint   `gorm:"index;not null" json:"task_id"`
+	ProjectID uint   `gorm:"index;not null" json:"project_id"`
+	ActorID   uint   `gorm:"index;not null" json:"actor_id"`
+	Type      string `gorm:"size:20;index;not null" json:"type"`
+	Field     string `gorm:"size:50" json:"field"`
+	OldValue  string `gorm:"type:text" json:"old_value"`
+	NewValue  string `gorm:"type:text" json:"new_value"`
+
+	Actor User `gorm:"foreignKey:ActorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"actor"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
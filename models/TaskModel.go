@@ -14,6 +14,10 @@ const (
 	TaskPriorityLow    = "LOW"
 	TaskPriorityMedium = "MEDIUM"
 	TaskPriorityHigh   = "HIGH"
+
+	// quand créer l'occurrence suivante d'une tâche récurrente
+	RecurrenceOnDone   = "ON_DONE"  // quand la tâche passe en DONE
+	RecurrenceSchedule = "SCHEDULE" // à l'échéance, même si pas terminée
 )
 
 type Task struct {
@@ -25,9 +29,41 @@ type Task struct {
 	Priority    string         `gorm:"size:20;default:MEDIUM" json:"priority"`
 	DueDate     *time.Time     `json:"due_date"`
 
+	SprintID *uint `gorm:"index" json:"sprint_id"`
+
+	// estimate in the scale of the project ("5", "M", "2.5") and its value
+	// in points, used by velocity reports
+	Estimate    string   `gorm:"size:10" json:"estimate"`
+	StoryPoints *float64 `json:"story_points"`
+
+	// estimates in minutes; remaining goes down as time is logged
+	OriginalEstimate  *int `json:"original_estimate_minutes"`
+	RemainingEstimate *int `json:"remaining_estimate_minutes"`
+
+	// set by the reminder scheduler, cleared when the due date changes
+	ReminderSentAt *time.Time `json:"reminder_sent_at"`
+	OverdueAt      *time.Time `gorm:"index" json:"overdue_at"`
+	EscalatedAt    *time.Time `json:"escalated_at"`
+
+	// recurrence rule (RRULE subset, see package recurrence); start and
+	// index locate this occurrence in its series
+	Recurrence         string     `gorm:"size:255" json:"recurrence,omitempty"`
+	RecurrenceMode     string     `gorm:"size:10" json:"recurrence_mode,omitempty"`
+	RecurrenceStart    *time.Time `json:"recurrence_start,omitempty"`
+	RecurrenceIndex    int        `gorm:"not null;default:0" json:"recurrence_index,omitempty"`
+	RecurrenceParentID *uint      `gorm:"index" json:"recurrence_parent_id,omitempty"`
+	RecurrenceNextID   *uint      `json:"recurrence_next_id,omitempty"`
+
+	// position inside the status column of the board (lexicographic order)
+	Rank string `gorm:"column:board_rank;size:64;index;not null;default:''" json:"rank"`
+
+	// optimistic locking (ETag); what bumps it: controllers/concurrency_helpers.go
+	Version uint `gorm:"not null;default:1" json:"version"`
+
 	CreatorID uint `gorm:"index;not null" json:"creator_id"`
 
 	Assignees []TaskAssignee `gorm:"foreignKey:TaskID" json:"assignees"`
+	Watchers  []TaskWatcher  `gorm:"foreignKey:TaskID" json:"watchers,omitempty"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TaskWatcher: a user following the changes of a task. Creator and
+// assignees start watching automatically; anyone can unwatch.
+type TaskWatcher struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	TaskID uint `gorm:"uniqueIndex:idx_task_watcher;not null" json:"task_id"`
+	UserID uint `gorm:"uniqueIndex:idx_task_watcher;index;not null" json:"user_id"`
+
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
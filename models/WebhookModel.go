@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DeliveryPending = "PENDING"
+	DeliverySuccess = "SUCCESS"
+	DeliveryFailed  = "FAILED" // gave up after the last retry
+)
+
+// Webhook is an endpoint of a project that receives its events as signed JSON
+type Webhook struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	ProjectID   uint   `gorm:"index;not null" json:"project_id"`
+	URL         string `gorm:"size:500;not null" json:"url"`
+	Secret      string `gorm:"size:100;not null" json:"-"`
+	Events      string `gorm:"type:text" json:"events"` // comma separated event types, "*" = all
+	Active      bool   `gorm:"not null;default:true" json:"active"`
+	CreatedByID uint   `gorm:"not null" json:"created_by_id"`
+
+	// reset on success; the webhook is disabled when it reaches the limit
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WebhookDelivery is one event to send to one webhook. Pending rows are the
+// persistent retry queue, the others form the delivery log.
+type WebhookDelivery struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	WebhookID     uint       `gorm:"index;not null" json:"webhook_id"`
+	EventType     string     `gorm:"size:50;not null" json:"event_type"`
+	Payload       string     `gorm:"type:mediumtext" json:"payload"`
+	Status        string     `gorm:"size:20;index;not null" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	ResponseCode  int        `json:"response_code"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
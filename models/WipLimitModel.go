@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Project.WipMode: what happens when a move would exceed a WIP limit
+const (
+	WipModeOff   = "OFF"
+	WipModeWarn  = "WARN"
+	WipModeBlock = "BLOCK"
+)
+
+// WipLimit caps the number of tasks in a status column of a project.
+// With AssigneeID set, the cap applies to the tasks of that assignee only.
+type WipLimit struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	ProjectID  uint   `gorm:"index;not null" json:"project_id"`
+	Status     string `gorm:"size:20;not null" json:"status"`
+	AssigneeID *uint  `gorm:"index" json:"assignee_id"`
+	MaxTasks   int    `gorm:"not null" json:"max_tasks"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkLog is time spent by a user on a task. A running timer has no
+// EndedAt and RunningUserID set: the unique index allows one running
+// timer per user.
+type WorkLog struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	TaskID    uint `gorm:"index;not null" json:"task_id"`
+	ProjectID uint `gorm:"index;not null" json:"project_id"`
+	UserID    uint `gorm:"index;not null" json:"user_id"`
+
+	StartedAt       time.Time  `gorm:"index;not null" json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationSeconds int64      `gorm:"not null;default:0" json:"duration_seconds"`
+	Note            string     `gorm:"size:1000" json:"note"`
+
+	RunningUserID *uint `gorm:"uniqueIndex" json:"-"`
+
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,354 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/mailer"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// BatchWindow is how long immediate emails wait so that several changes
+// to the same task end up in one email
+const BatchWindow = 2 * time.Minute
+
+//go:embed templates/email.txt templates/email.html
+var templateFS embed.FS
+
+var (
+	textTmpl = template.Must(template.ParseFS(templateFS, "templates/email.txt"))
+	htmlTmpl = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/email.html"))
+)
+
+type emailItem struct {
+	Type    string
+	Message string
+	At      time.Time
+}
+
+type emailGroup struct {
+	Title   string
+	Project string
+	Link    string
+	Items   []emailItem
+}
+
+type emailData struct {
+	Name           string
+	Digest         bool
+	Count          int
+	Groups         []emailGroup
+	UnsubscribeURL string
+}
+
+// AppURL is the public base URL used in links (APP_URL, default http://localhost:3000)
+func AppURL() string {
+	u := os.Getenv("APP_URL")
+	if u == "" {
+		u = "http://localhost:3000"
+	}
+	return strings.TrimRight(u, "/")
+}
+
+// TaskURL links to a task in the web app
+func TaskURL(projectID, taskID uint) string {
+	return fmt.Sprintf("%s/?project=%d&task=%d", AppURL(), projectID, taskID)
+}
+
+func linkSecret() []byte {
+	if s := os.Getenv("EMAIL_LINK_SECRET"); s != "" {
+		return []byte(s)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// UnsubscribeToken signs the unsubscribe link of a user
+func UnsubscribeToken(userID uint) string {
+	mac := hmac.New(sha256.New, linkSecret())
+	mac.Write([]byte("unsubscribe:" + strconv.FormatUint(uint64(userID), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyUnsubscribeToken checks a token built by UnsubscribeToken
+func VerifyUnsubscribeToken(userID uint, token string) bool {
+	return hmac.Equal([]byte(UnsubscribeToken(userID)), []byte(token))
+}
+
+// UnsubscribeURL is the one-click unsubscribe link put in every email
+func UnsubscribeURL(userID uint) string {
+	return fmt.Sprintf("%s/api/email/unsubscribe?uid=%d&token=%s", AppURL(), userID, UnsubscribeToken(userID))
+}
+
+// EmailPreferenceOf returns the preference of a user, or the default one
+func EmailPreferenceOf(userID uint) (models.EmailPreference, error) {
+	pref := models.EmailPreference{UserID: userID, Mode: models.EmailImmediate, DigestHour: 8}
+	err := initializers.DB.Where("user_id = ?", userID).Limit(1).Find(&pref).Error
+	return pref, err
+}
+
+// SetEmailMode stores the mode of a user; switching to OFF drops the
+// emails still waiting in the queue
+func SetEmailMode(userID uint, mode string, digestHour *int) (models.EmailPreference, error) {
+	pref, err := EmailPreferenceOf(userID)
+	if err != nil {
+		return pref, err
+	}
+	pref.Mode = mode
+	if digestHour != nil {
+		pref.DigestHour = *digestHour
+	}
+	if err := initializers.DB.Save(&pref).Error; err != nil {
+		return pref, err
+	}
+	if mode == models.EmailOff {
+		err = initializers.DB.Model(&models.EmailQueueItem{}).
+			Where("user_id = ? AND sent_at IS NULL", userID).
+			Update("sent_at", time.Now()).Error
+	}
+	return pref, err
+}
+
+// queueEmail puts a notification in the email queue unless the user
+// turned emails off
+func queueEmail(notif models.Notification) {
+	pref, err := EmailPreferenceOf(notif.UserID)
+	if err != nil {
+		log.Printf("notifications: could not read email preference of user %d: %v", notif.UserID, err)
+		return
+	}
+	if pref.Mode == models.EmailOff {
+		return
+	}
+	item := models.EmailQueueItem{
+		UserID:         notif.UserID,
+		NotificationID: notif.ID,
+		ProjectID:      notif.ProjectID,
+		TaskID:         notif.TaskID,
+		Type:           notif.Type,
+		Message:        notif.Message,
+	}
+	if err := initializers.DB.Create(&item).Error; err != nil {
+		log.Printf("notifications: could not queue email for user %d: %v", notif.UserID, err)
+	}
+}
+
+// StartEmailWorker sends the queued emails every interval
+func StartEmailWorker(m mailer.Mailer, interval time.Duration) {
+	go func() {
+		for {
+			if err := SendDueEmails(m, time.Now()); err != nil {
+				log.Printf("notifications: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SendDueEmails sends one email per user whose queue is ready at now:
+// immediate users once their oldest item is older than BatchWindow,
+// digest users once a day after their digest hour.
+func SendDueEmails(m mailer.Mailer, now time.Time) error {
+	var userIDs []uint
+	if err := initializers.DB.Model(&models.EmailQueueItem{}).
+		Where("sent_at IS NULL").
+		Distinct("user_id").
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	for _, uid := range userIDs {
+		pref, err := EmailPreferenceOf(uid)
+		if err != nil {
+			log.Printf("notifications: could not read email preference of user %d: %v", uid, err)
+			continue
+		}
+
+		switch pref.Mode {
+		case models.EmailOff:
+			continue
+		case models.EmailDigest:
+			due := time.Date(now.Year(), now.Month(), now.Day(), pref.DigestHour, 0, 0, 0, now.Location())
+			if now.Before(due) || (pref.LastDigestAt != nil && !pref.LastDigestAt.Before(due)) {
+				continue
+			}
+		default:
+			var oldest models.EmailQueueItem
+			if err := initializers.DB.Where("user_id = ? AND sent_at IS NULL", uid).
+				Order("created_at").First(&oldest).Error; err != nil {
+				continue
+			}
+			if now.Sub(oldest.CreatedAt) < BatchWindow {
+				continue
+			}
+		}
+
+		if err := sendUserEmail(m, pref, now); err != nil {
+			log.Printf("notifications: could not email user %d: %v", uid, err)
+		}
+	}
+	return nil
+}
+
+// sendUserEmail claims the pending items of a user and mails them
+func sendUserEmail(m mailer.Mailer, pref models.EmailPreference, now time.Time) error {
+	// claim the rows: another instance running the same loop gets
+	// RowsAffected == 0 and stops here
+	claimedAt := now.Truncate(time.Millisecond)
+	res := initializers.DB.Model(&models.EmailQueueItem{}).
+		Where("user_id = ? AND sent_at IS NULL", pref.UserID).
+		Update("sent_at", claimedAt)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+
+	var items []models.EmailQueueItem
+	if err := initializers.DB.Where("user_id = ? AND sent_at = ?", pref.UserID, claimedAt).
+		Order("created_at, id").Find(&items).Error; err != nil {
+		return err
+	}
+
+	digest := pref.Mode == models.EmailDigest
+	err := func() error {
+		var user models.User
+		if err := initializers.DB.First(&user, pref.UserID).Error; err != nil {
+			return err
+		}
+		msg, err := renderEmail(user, items, digest)
+		if err != nil {
+			return err
+		}
+		return m.Send(msg)
+	}()
+	if err != nil {
+		// give the items back to the queue for the next run
+		initializers.DB.Model(&models.EmailQueueItem{}).
+			Where("user_id = ? AND sent_at = ?", pref.UserID, claimedAt).
+			Update("sent_at", nil)
+		return err
+	}
+
+	if digest {
+		initializers.DB.Model(&models.EmailPreference{}).
+			Where("user_id = ?", pref.UserID).
+			Update("last_digest_at", now)
+	}
+	return nil
+}
+
+// renderEmail groups the items by task and renders both templates
+func renderEmail(user models.User, items []models.EmailQueueItem, digest bool) (mailer.Message, error) {
+	taskIDs := []uint{}
+	projectIDs := []uint{}
+	for _, it := range items {
+		if it.TaskID != nil {
+			taskIDs = append(taskIDs, *it.TaskID)
+		}
+		if it.ProjectID != nil {
+			projectIDs = append(projectIDs, *it.ProjectID)
+		}
+	}
+	titles := map[uint]string{}
+	if len(taskIDs) > 0 {
+		var tasks []models.Task
+		initializers.DB.Unscoped().Select("id", "title").Where("id IN ?", taskIDs).Find(&tasks)
+		for _, t := range tasks {
+			titles[t.ID] = t.Title
+		}
+	}
+	projects := map[uint]string{}
+	if len(projectIDs) > 0 {
+		var ps []models.Project
+		initializers.DB.Unscoped().Select("id", "name").Where("id IN ?", projectIDs).Find(&ps)
+		for _, p := range ps {
+			projects[p.ID] = p.Name
+		}
+	}
+
+	// one group per task, in order of first appearance; other items
+	// (memberships...) share a group per project
+	groups := []*emailGroup{}
+	index := map[string]*emailGroup{}
+	for _, it := range items {
+		key, title, link := "", "", ""
+		var project string
+		if it.ProjectID != nil {
+			project = projects[*it.ProjectID]
+		}
+		switch {
+		case it.TaskID != nil:
+			key = "t" + strconv.FormatUint(uint64(*it.TaskID), 10)
+			title = titles[*it.TaskID]
+			if it.ProjectID != nil {
+				link = TaskURL(*it.ProjectID, *it.TaskID)
+			}
+		case it.ProjectID != nil:
+			key = "p" + strconv.FormatUint(uint64(*it.ProjectID), 10)
+			title, project = project, ""
+			link = fmt.Sprintf("%s/?project=%d", AppURL(), *it.ProjectID)
+		default:
+			key, title = "other", "Other"
+		}
+		if title == "" {
+			title = "(deleted)"
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &emailGroup{Title: title, Project: project, Link: link}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.Items = append(g.Items, emailItem{Type: it.Type, Message: it.Message, At: it.CreatedAt})
+	}
+
+	data := emailData{
+		Name:           user.Name,
+		Digest:         digest,
+		Count:          len(items),
+		UnsubscribeURL: UnsubscribeURL(user.ID),
+	}
+	for _, g := range groups {
+		data.Groups = append(data.Groups, *g)
+	}
+
+	var text, html bytes.Buffer
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return mailer.Message{}, err
+	}
+
+	subject := items[0].Message
+	switch {
+	case digest:
+		subject = fmt.Sprintf("Your daily digest: %d updates", len(items))
+	case len(groups) == 1 && len(items) > 1:
+		subject = fmt.Sprintf("%d updates on %q", len(items), groups[0].Title)
+	case len(items) > 1:
+		subject = fmt.Sprintf("%d new updates", len(items))
+	}
+
+	return mailer.Message{
+		To:      (&mail.Address{Name: user.Name, Address: user.Email}).String(),
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + data.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
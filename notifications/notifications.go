@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"log"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// Notice is what a caller wants to tell users; zero ids mean "none"
+type Notice struct {
+	Type      string
+	ProjectID uint
+	TaskID    uint
+	ActorID   uint
+	Message   string
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// Muted reports whether userID muted this type of notice for this project
+func Muted(userID uint, typ string, projectID uint) (bool, error) {
+	q := initializers.DB.Model(&models.NotificationMute{}).
+		Where("user_id = ?", userID).
+		Where("type = '' OR type IS NULL OR type = ?", typ)
+	if projectID != 0 {
+		q = q.Where("project_id IS NULL OR project_id = ?", projectID)
+	} else {
+		q = q.Where("project_id IS NULL")
+	}
+	var n int64
+	err := q.Count(&n).Error
+	return n > 0, err
+}
+
+// Notify creates the notice for each user, skipping the actor,
+// duplicates and users who muted it. Errors are logged, never returned:
+// a notification must not make the change that caused it fail.
+func Notify(userIDs []uint, n Notice) []models.Notification {
+	created := []models.Notification{}
+	seen := map[uint]bool{}
+	for _, uid := range userIDs {
+		if uid == 0 || uid == n.ActorID || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		muted, err := Muted(uid, n.Type, n.ProjectID)
+		if err != nil {
+			log.Printf("notifications: could not read preferences of user %d: %v", uid, err)
+			continue
+		}
+		if muted {
+			continue
+		}
+
+		notif := models.Notification{
+			UserID:    uid,
+			Type:      n.Type,
+			ProjectID: optionalID(n.ProjectID),
+			TaskID:    optionalID(n.TaskID),
+			ActorID:   optionalID(n.ActorID),
+			Message:   n.Message,
+		}
+		if err := initializers.DB.Create(&notif).Error; err != nil {
+			log.Printf("notifications: could not notify user %d: %v", uid, err)
+			continue
+		}
+		queueEmail(notif)
+		created = append(created, notif)
+	}
+	return created
+}
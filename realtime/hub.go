@@ -0,0 +1,158 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published by the controllers
+const (
+	TaskCreated       = "task.created"
+	TaskUpdated       = "task.updated"
+	TaskStatusChanged = "task.status_changed"
+	TaskMoved         = "task.moved"
+	TaskDeleted       = "task.deleted"
+	TaskAssigned      = "task.assigned"
+	TaskUnassigned    = "task.unassigned"
+	MemberAdded       = "member.added"
+	MemberRemoved     = "member.removed"
+	ProjectUpdated    = "project.updated"
+	ProjectDeleted    = "project.deleted"
+	SprintStarted     = "sprint.started"
+	SprintClosed      = "sprint.closed"
+	TasksImported     = "tasks.imported"
+	TaskTransferred   = "task.transferred"
+)
+
+// EventTypes lists every type above, e.g. to validate subscriptions
+var EventTypes = []string{
+	TaskCreated, TaskUpdated, TaskStatusChanged, TaskMoved, TaskDeleted,
+	TaskAssigned, TaskUnassigned, MemberAdded, MemberRemoved,
+	ProjectUpdated, ProjectDeleted, SprintStarted, SprintClosed, TasksImported,
+	TaskTransferred,
+}
+
+// Event is one change in a project. IDs increase across all projects, and
+// across restarts, so a client can resume from the last id it saw.
+type Event struct {
+	ID        uint64      `json:"id"`
+	ProjectID uint        `json:"project_id"`
+	Type      string      `json:"type"`
+	ActorID   uint        `json:"actor_id"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// subscriberBuffer is how many events a slow connection may lag behind
+// before it gets dropped (it can then reconnect with Last-Event-ID)
+const subscriberBuffer = 64
+
+// Hub is an in-process pub/sub: each project has its subscribers and a short
+// history used to replay events after a reconnection.
+type Hub struct {
+	mu          sync.Mutex
+	epoch       uint64 // ids of this process are > epoch
+	nextID      uint64
+	historySize int
+	subs        map[uint]map[chan Event]struct{}
+	history     map[uint][]Event
+	truncated   map[uint]uint64 // id of the newest event dropped from history
+	listeners   []func(Event)
+}
+
+func NewHub(historySize int) *Hub {
+	// ids partent de l'heure de démarrage (µs, sûr pour un nombre JavaScript) :
+	// ils ne reculent pas après un redémarrage
+	epoch := uint64(time.Now().UnixMicro())
+	return &Hub{
+		epoch:       epoch,
+		nextID:      epoch,
+		historySize: historySize,
+		subs:        map[uint]map[chan Event]struct{}{},
+		history:     map[uint][]Event{},
+		truncated:   map[uint]uint64{},
+	}
+}
+
+// DefaultHub is the hub used by the server
+var DefaultHub = NewHub(500)
+
+// Listen registers fn to be called synchronously for every published event,
+// for consumers that are not connections (webhooks, caches, ...)
+func (h *Hub) Listen(fn func(Event)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, fn)
+}
+
+// Publish records the event and sends it to the project's subscribers
+func (h *Hub) Publish(projectID uint, typ string, actorID uint, data interface{}) Event {
+	h.mu.Lock()
+	h.nextID++
+	ev := Event{
+		ID:        h.nextID,
+		ProjectID: projectID,
+		Type:      typ,
+		ActorID:   actorID,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	hist := append(h.history[projectID], ev)
+	if len(hist) > h.historySize {
+		drop := len(hist) - h.historySize
+		h.truncated[projectID] = hist[drop-1].ID
+		hist = append([]Event(nil), hist[drop:]...)
+	}
+	h.history[projectID] = hist
+
+	for ch := range h.subs[projectID] {
+		select {
+		case ch <- ev:
+		default:
+			// too slow: drop the connection rather than block everybody
+			delete(h.subs[projectID], ch)
+			close(ch)
+		}
+	}
+	listeners := h.listeners
+	h.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(ev)
+	}
+	return ev
+}
+
+// Subscribe registers a connection to a project. Events after lastEventID
+// that are still in history are returned as missed; resync is true when
+// some of them are gone and the client should reload instead.
+func (h *Hub) Subscribe(projectID uint, lastEventID uint64) (events <-chan Event, missed []Event, resync bool, cancel func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if lastEventID > 0 {
+		// avant le démarrage de ce processus (ou inconnu) : historique perdu
+		resync = lastEventID < h.truncated[projectID] || lastEventID <= h.epoch || lastEventID > h.nextID
+		for _, ev := range h.history[projectID] {
+			if ev.ID > lastEventID {
+				missed = append(missed, ev)
+			}
+		}
+	}
+	if h.subs[projectID] == nil {
+		h.subs[projectID] = map[chan Event]struct{}{}
+	}
+	h.subs[projectID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[projectID][ch]; ok {
+			delete(h.subs[projectID], ch)
+			close(ch)
+		}
+	}
+	return ch, missed, resync, cancel
+}
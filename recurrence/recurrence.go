@@ -0,0 +1,288 @@
+// Package recurrence implements the subset of RFC 5545 RRULE used by
+// recurring tasks: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, UNTIL, COUNT.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+
+	// maxPeriods bounds the search for the next occurrence
+	maxPeriods = 10000
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var weekdayNames = map[time.Weekday]string{}
+
+func init() {
+	for name, d := range weekdays {
+		weekdayNames[d] = name
+	}
+}
+
+// Weekday of BYDAY; N is the ordinal for MONTHLY rules (1MO = first
+// Monday, -1FR = last Friday), 0 means every such day
+type Weekday struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed RRULE
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []Weekday
+	Until    *time.Time
+	Count    int
+}
+
+// Parse reads an RRULE such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"
+// (an "RRULE:" prefix is accepted)
+func Parse(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return r, errors.New("empty rule")
+	}
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return r, fmt.Errorf("invalid rule part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly {
+				return r, fmt.Errorf("unsupported FREQ %q (DAILY, WEEKLY or MONTHLY)", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid COUNT %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := parseUntil(value)
+			if err != nil {
+				return r, fmt.Errorf("invalid UNTIL %q", value)
+			}
+			r.Until = &t
+		case "BYDAY":
+			for _, d := range strings.Split(strings.ToUpper(value), ",") {
+				wd, err := parseWeekday(d)
+				if err != nil {
+					return r, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				return r, errors.New("only WKST=MO is supported")
+			}
+		default:
+			return r, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return r, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return r, errors.New("COUNT and UNTIL cannot be used together")
+	}
+	if r.Freq != Monthly {
+		for _, d := range r.ByDay {
+			if d.N != 0 {
+				return r, errors.New("BYDAY ordinals are only allowed with FREQ=MONTHLY")
+			}
+		}
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.ParseInLocation(layout, v, time.UTC); err == nil {
+			if layout == "20060102" {
+				// whole day included
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("bad date")
+}
+
+func parseWeekday(s string) (Weekday, error) {
+	if len(s) < 2 {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return Weekday{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+	}
+	return Weekday{N: n, Day: day}, nil
+}
+
+// String returns the canonical form of the rule
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdayNames[d.Day]
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after prev of the series
+// starting at start, with its 1-based index in the series. ok is false
+// when the series has ended (COUNT or UNTIL reached).
+func (r Rule) Next(start, prev time.Time) (next time.Time, index int, ok bool) {
+	for p := 0; p < maxPeriods; p++ {
+		for _, t := range r.period(start, p) {
+			if t.Before(start) {
+				continue
+			}
+			index++
+			if r.Count > 0 && index > r.Count {
+				return time.Time{}, 0, false
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return time.Time{}, 0, false
+			}
+			if t.After(prev) {
+				return t, index, true
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// at builds a date with the clock time of start
+func at(start time.Time, y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+}
+
+func (r Rule) matches(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Day == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// period returns the sorted candidates of the p-th period of the series
+func (r Rule) period(start time.Time, p int) []time.Time {
+	switch r.Freq {
+	case Daily:
+		t := at(start, start.Year(), start.Month(), start.Day()+p*r.Interval)
+		if !r.matches(t) {
+			return nil
+		}
+		return []time.Time{t}
+
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{at(start, start.Year(), start.Month(), start.Day()+7*p*r.Interval)}
+		}
+		// weeks start on Monday
+		offset := (int(start.Weekday()) + 6) % 7
+		monday := at(start, start.Year(), start.Month(), start.Day()-offset+7*p*r.Interval)
+		out := []time.Time{}
+		for i := 0; i < 7; i++ {
+			t := at(start, monday.Year(), monday.Month(), monday.Day()+i)
+			if r.matches(t) {
+				out = append(out, t)
+			}
+		}
+		return out
+
+	default: // Monthly
+		first := time.Date(start.Year(), start.Month()+time.Month(p*r.Interval), 1, 0, 0, 0, 0, start.Location())
+		y, m := first.Year(), first.Month()
+		daysIn := time.Date(y, m+1, 0, 0, 0, 0, 0, start.Location()).Day()
+
+		if len(r.ByDay) == 0 {
+			// like RFC 5545, months without this day are skipped
+			if start.Day() > daysIn {
+				return nil
+			}
+			return []time.Time{at(start, y, m, start.Day())}
+		}
+
+		out := []time.Time{}
+		for _, d := range r.ByDay {
+			var days []int
+			for day := 1; day <= daysIn; day++ {
+				if time.Date(y, m, day, 0, 0, 0, 0, start.Location()).Weekday() == d.Day {
+					days = append(days, day)
+				}
+			}
+			switch {
+			case d.N == 0:
+				for _, day := range days {
+					out = append(out, at(start, y, m, day))
+				}
+			case d.N > 0 && d.N <= len(days):
+				out = append(out, at(start, y, m, days[d.N-1]))
+			case d.N < 0 && -d.N <= len(days):
+				out = append(out, at(start, y, m, days[len(days)+d.N]))
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+		uniq := out[:0]
+		for i, t := range out {
+			if i == 0 || !t.Equal(out[i-1]) {
+				uniq = append(uniq, t)
+			}
+		}
+		return uniq
+	}
+}
@@ -0,0 +1,137 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// day is 09:00 UTC on a date of 2026 ("01-30")
+func day(md string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", "2026-"+md+" 09:00")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// series lists up to n occurrences from start, as "01-30" dates
+func series(t *testing.T, r Rule, start time.Time, n int) []string {
+	var out []string
+	prev := start.Add(-time.Second)
+	for len(out) < n {
+		next, index, ok := r.Next(start, prev)
+		if !ok {
+			break
+		}
+		if index != len(out)+1 {
+			t.Fatalf("%s: occurrence %s has index %d, want %d", r, next.Format("01-02"), index, len(out)+1)
+		}
+		out = append(out, next.Format("01-02"))
+		prev = next
+	}
+	return out
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		n     int
+		want  string // "" = aucune occurrence
+	}{
+		{"daily interval", "FREQ=DAILY;INTERVAL=2", "01-30", 4, "01-30,02-01,02-03,02-05"},
+		{"daily byday", "FREQ=DAILY;BYDAY=MO,FR", "01-30", 4, "01-30,02-02,02-06,02-09"},
+		{"weekly interval", "FREQ=WEEKLY;INTERVAL=2", "01-07", 3, "01-07,01-21,02-04"},
+		// semaines du lundi : le lundi 01-05 est avant le début, ignoré
+		{"weekly byday from midweek", "FREQ=WEEKLY;BYDAY=MO,TH", "01-07", 4, "01-08,01-12,01-15,01-19"},
+		// le dimanche clôt la semaine du lundi 01-05, pas celle du 01-12
+		{"weekly sunday alignment", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", "01-07", 3, "01-11,01-25,02-08"},
+		{"monthly day 31 skips short months", "FREQ=MONTHLY", "01-31", 4, "01-31,03-31,05-31,07-31"},
+		{"monthly day 29 skips february", "FREQ=MONTHLY", "01-29", 3, "01-29,03-29,04-29"},
+		{"monthly first monday", "FREQ=MONTHLY;BYDAY=1MO", "01-01", 4, "01-05,02-02,03-02,04-06"},
+		{"monthly last friday", "FREQ=MONTHLY;BYDAY=-1FR", "01-01", 3, "01-30,02-27,03-27"},
+		{"monthly fifth monday", "FREQ=MONTHLY;BYDAY=5MO", "01-01", 2, "03-30,06-29"},
+		{"monthly two ordinals", "FREQ=MONTHLY;BYDAY=1MO,-1MO", "01-01", 4, "01-05,01-26,02-02,02-23"},
+		{"count", "FREQ=DAILY;COUNT=3", "01-01", 10, "01-01,01-02,01-03"},
+		{"count when start does not match", "FREQ=WEEKLY;BYDAY=MO;COUNT=2", "01-07", 10, "01-12,01-19"},
+		{"until whole day", "FREQ=WEEKLY;UNTIL=20260115", "01-01", 10, "01-01,01-08,01-15"},
+		{"until before clock time", "FREQ=DAILY;UNTIL=20260103T080000Z", "01-01", 10, "01-01,01-02"},
+		{"until before start", "FREQ=DAILY;UNTIL=20251231", "01-01", 10, ""},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: Parse(%q): %v", tt.name, tt.rule, err)
+		}
+		got := strings.Join(series(t, r, day(tt.start), tt.n), ",")
+		if got != tt.want {
+			t.Errorf("%s: %s from %s = %s, want %s", tt.name, tt.rule, tt.start, got, tt.want)
+		}
+	}
+}
+
+func TestNextFromTheMiddle(t *testing.T) {
+	r, _ := Parse("FREQ=DAILY;COUNT=10")
+	next, index, ok := r.Next(day("01-01"), day("01-05"))
+	if !ok || next.Format("01-02") != "01-06" || index != 6 {
+		t.Fatalf("Next after 01-05 = %s #%d ok=%v, want 01-06 #6", next.Format("01-02"), index, ok)
+	}
+	if _, _, ok := r.Next(day("01-01"), day("01-10")); ok {
+		t.Fatal("Next after the 10th occurrence of COUNT=10 should end the series")
+	}
+}
+
+func TestNextKeepsClockTime(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*3600)
+	start := time.Date(2026, 1, 31, 18, 30, 0, 0, loc)
+	r, _ := Parse("FREQ=MONTHLY")
+	next, _, ok := r.Next(start, start)
+	want := time.Date(2026, 3, 31, 18, 30, 0, 0, loc)
+	if !ok || !next.Equal(want) || next.Location() != loc {
+		t.Fatalf("Next = %v, want %v", next, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	valid := []struct{ in, want string }{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=monthly;byday=-1fr;interval=2", "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR"},
+		{"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH;WKST=MO", "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{"FREQ=WEEKLY;COUNT=5", "FREQ=WEEKLY;COUNT=5"},
+		{"FREQ=DAILY;UNTIL=20260115", "FREQ=DAILY;UNTIL=20260115T235959Z"},
+	}
+	for _, tt := range valid {
+		r, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;WKST=SU",
+		"FREQ=DAILY;BYMONTH=1",
+		"FREQ=DAILY;COUNT",
+	}
+	for _, in := range invalid {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) accepted", in)
+		}
+	}
+}
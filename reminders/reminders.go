@@ -0,0 +1,235 @@
+package reminders
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/notifications"
+)
+
+const (
+	lockName  = "due-date-reminders"
+	batchSize = 100
+)
+
+// Clock gives the current time; tests inject a fixed or manual clock
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to Clock
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time { return f() }
+
+// SystemClock is the real clock
+var SystemClock Clock = ClockFunc(time.Now)
+
+// Scheduler sends due-date reminders, flags overdue tasks and escalates
+// them to the project owner
+type Scheduler struct {
+	DB    *gorm.DB
+	Clock Clock
+
+	// RemindBefore: how long before the due date assignees are reminded
+	RemindBefore time.Duration
+	// EscalateAfter: grace period after the due date before the project
+	// owner is told; 0 disables escalation
+	EscalateAfter time.Duration
+	// Lease: how long the leader lock is held after each run
+	Lease time.Duration
+
+	holder string
+}
+
+// New builds a scheduler configured by REMINDER_HOURS (default 24) and
+// OVERDUE_ESCALATION_HOURS (default 0 = off)
+func New(db *gorm.DB, clock Clock) *Scheduler {
+	return &Scheduler{
+		DB:            db,
+		Clock:         clock,
+		RemindBefore:  envHours("REMINDER_HOURS", 24),
+		EscalateAfter: envHours("OVERDUE_ESCALATION_HOURS", 0),
+		Lease:         2 * time.Minute,
+		holder:        newHolder(),
+	}
+}
+
+func envHours(key string, def int) time.Duration {
+	h, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || h < 0 {
+		h = def
+	}
+	return time.Duration(h) * time.Hour
+}
+
+func newHolder() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Start runs the scheduler every interval
+func (s *Scheduler) Start(interval time.Duration) {
+	go func() {
+		for {
+			if err := s.RunOnce(); err != nil {
+				log.Printf("reminders: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// RunOnce does one pass if this instance holds the leader lock
+func (s *Scheduler) RunOnce() error {
+	now := s.Clock.Now()
+	leader, err := s.acquire(now)
+	if err != nil || !leader {
+		return err
+	}
+	if err := s.remindDueSoon(now); err != nil {
+		return err
+	}
+	if err := s.flagOverdue(now); err != nil {
+		return err
+	}
+	if s.EscalateAfter > 0 {
+		return s.escalate(now)
+	}
+	return nil
+}
+
+// acquire takes or renews the lease on the lock row
+func (s *Scheduler) acquire(now time.Time) (bool, error) {
+	if err := s.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.SchedulerLock{Name: lockName, Holder: "", ExpiresAt: time.Unix(0, 0)}).Error; err != nil {
+		return false, err
+	}
+	if err := s.DB.Model(&models.SchedulerLock{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", lockName, s.holder, now).
+		Updates(map[string]interface{}{"holder": s.holder, "expires_at": now.Add(s.Lease)}).Error; err != nil {
+		return false, err
+	}
+	// on relit le détenteur : MySQL compte les lignes modifiées, pas trouvées,
+	// donc un renouvellement à l'identique donne RowsAffected = 0
+	var lock models.SchedulerLock
+	if err := s.DB.Where("name = ?", lockName).First(&lock).Error; err != nil {
+		return false, err
+	}
+	return lock.Holder == s.holder, nil
+}
+
+// claim marks column on a task; false when it was already set
+func (s *Scheduler) claim(taskID uint, column string, now time.Time) bool {
+	res := s.DB.Model(&models.Task{}).
+		Where("id = ? AND "+column+" IS NULL", taskID).
+		UpdateColumn(column, now)
+	if res.Error != nil {
+		log.Printf("reminders: could not update task %d: %v", taskID, res.Error)
+	}
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+// assigneesOf returns the assignees of a task, or its creator when nobody is assigned
+func (s *Scheduler) assigneesOf(task models.Task) []uint {
+	var ids []uint
+	if err := s.DB.Model(&models.TaskAssignee{}).
+		Where("task_id = ?", task.ID).
+		Pluck("user_id", &ids).Error; err != nil {
+		log.Printf("reminders: could not load assignees of task %d: %v", task.ID, err)
+	}
+	if len(ids) == 0 {
+		ids = []uint{task.CreatorID}
+	}
+	return ids
+}
+
+func (s *Scheduler) openTasks() *gorm.DB {
+	return s.DB.Model(&models.Task{}).
+		Where("due_date IS NOT NULL AND status <> ?", models.TaskStatusDone).
+		Order("due_date").
+		Limit(batchSize)
+}
+
+// remindDueSoon reminds the assignees of tasks due within RemindBefore
+func (s *Scheduler) remindDueSoon(now time.Time) error {
+	var tasks []models.Task
+	if err := s.openTasks().
+		Where("reminder_sent_at IS NULL AND due_date > ? AND due_date <= ?", now, now.Add(s.RemindBefore)).
+		Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if !s.claim(t.ID, "reminder_sent_at", now) {
+			continue
+		}
+		notifications.Notify(s.assigneesOf(t), notifications.Notice{
+			Type:      models.NotifDueSoon,
+			ProjectID: t.ProjectID,
+			TaskID:    t.ID,
+			Message:   fmt.Sprintf("%q is due %s", t.Title, t.DueDate.Format("Mon Jan 2 15:04")),
+		})
+	}
+	return nil
+}
+
+// flagOverdue sets overdue_at on tasks past their due date and tells the assignees
+func (s *Scheduler) flagOverdue(now time.Time) error {
+	var tasks []models.Task
+	if err := s.openTasks().
+		Where("overdue_at IS NULL AND due_date <= ?", now).
+		Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if !s.claim(t.ID, "overdue_at", now) {
+			continue
+		}
+		notifications.Notify(s.assigneesOf(t), notifications.Notice{
+			Type:      models.NotifOverdue,
+			ProjectID: t.ProjectID,
+			TaskID:    t.ID,
+			Message:   fmt.Sprintf("%q is overdue", t.Title),
+		})
+	}
+	return nil
+}
+
+// escalate tells the project owner about tasks still open EscalateAfter
+// past their due date
+func (s *Scheduler) escalate(now time.Time) error {
+	var tasks []models.Task
+	if err := s.openTasks().
+		Where("overdue_at IS NOT NULL AND escalated_at IS NULL AND due_date <= ?", now.Add(-s.EscalateAfter)).
+		Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if !s.claim(t.ID, "escalated_at", now) {
+			continue
+		}
+		var project models.Project
+		if err := s.DB.Select("id", "owner_id").First(&project, t.ProjectID).Error; err != nil || project.OwnerID == nil {
+			continue
+		}
+		late := now.Sub(*t.DueDate).Round(time.Hour)
+		notifications.Notify([]uint{*project.OwnerID}, notifications.Notice{
+			Type:      models.NotifEscalated,
+			ProjectID: t.ProjectID,
+			TaskID:    t.ID,
+			Message:   fmt.Sprintf("%q is overdue by %s", t.Title, late),
+		})
+	}
+	return nil
+}
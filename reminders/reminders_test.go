@@ -0,0 +1,231 @@
+package reminders
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+)
+
+// manualClock is a clock the test moves by hand
+type manualClock struct{ t time.Time }
+
+func (c *manualClock) Now() time.Time          { return c.t }
+func (c *manualClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// testDB is an in-memory database with the tables the scheduler touches;
+// notifications are written through initializers.DB, so it is set too
+func testDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Project{},
+		&models.Task{},
+		&models.TaskAssignee{},
+		&models.Notification{},
+		&models.NotificationMute{},
+		&models.EmailPreference{},
+		&models.EmailQueueItem{},
+		&models.SchedulerLock{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	prev := initializers.DB
+	initializers.DB = db
+	t.Cleanup(func() {
+		initializers.DB = prev
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+const (
+	ownerID    = 1
+	assigneeID = 2
+)
+
+func newTestScheduler(t *testing.T) (*Scheduler, *manualClock) {
+	db := testDB(t)
+	owner := uint(ownerID)
+	if err := db.Create(&models.Project{ID: 1, Name: "p", OwnerID: &owner}).Error; err != nil {
+		t.Fatal(err)
+	}
+	clock := &manualClock{t: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+	s := &Scheduler{
+		DB:            db,
+		Clock:         clock,
+		RemindBefore:  24 * time.Hour,
+		EscalateAfter: 48 * time.Hour,
+		Lease:         2 * time.Minute,
+		holder:        "test-a",
+	}
+	return s, clock
+}
+
+// addTask creates a task of project 1 due in `due`, assigned to assigneeID
+func addTask(t *testing.T, s *Scheduler, due time.Duration, status string) models.Task {
+	d := s.Clock.Now().Add(due)
+	task := models.Task{ProjectID: 1, Title: "task", Status: status, DueDate: &d, CreatorID: ownerID}
+	if err := s.DB.Create(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DB.Create(&models.TaskAssignee{TaskID: task.ID, UserID: assigneeID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// notices counts the notifications of a type for a user and a task
+func notices(t *testing.T, s *Scheduler, userID uint, typ string, taskID uint) int64 {
+	var n int64
+	if err := s.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND type = ? AND task_id = ?", userID, typ, taskID).
+		Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func runOnce(t *testing.T, s *Scheduler) {
+	if err := s.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReminderTiming(t *testing.T) {
+	s, clock := newTestScheduler(t)
+	soon := addTask(t, s, 10*time.Hour, models.TaskStatusTodo)
+	later := addTask(t, s, 30*time.Hour, models.TaskStatusTodo)
+	done := addTask(t, s, 5*time.Hour, models.TaskStatusDone)
+
+	runOnce(t, s)
+	if n := notices(t, s, assigneeID, models.NotifDueSoon, soon.ID); n != 1 {
+		t.Fatalf("task due in 10h: %d reminders, want 1", n)
+	}
+	if n := notices(t, s, assigneeID, models.NotifDueSoon, later.ID); n != 0 {
+		t.Fatalf("task due in 30h: %d reminders, want 0", n)
+	}
+	if n := notices(t, s, assigneeID, models.NotifDueSoon, done.ID); n != 0 {
+		t.Fatalf("done task: %d reminders, want 0", n)
+	}
+
+	// 6h plus tard : la seconde tâche entre dans la fenêtre, la première
+	// n'est pas rappelée deux fois
+	clock.advance(6*time.Hour + time.Minute)
+	runOnce(t, s)
+	if n := notices(t, s, assigneeID, models.NotifDueSoon, soon.ID); n != 1 {
+		t.Fatalf("task due in 10h after a second run: %d reminders, want 1", n)
+	}
+	if n := notices(t, s, assigneeID, models.NotifDueSoon, later.ID); n != 1 {
+		t.Fatalf("task due in 30h after 6h: %d reminders, want 1", n)
+	}
+}
+
+func TestOverdueAndEscalationTiming(t *testing.T) {
+	s, clock := newTestScheduler(t)
+	task := addTask(t, s, time.Hour, models.TaskStatusDoing)
+
+	runOnce(t, s)
+	if n := notices(t, s, assigneeID, models.NotifOverdue, task.ID); n != 0 {
+		t.Fatalf("before the due date: %d overdue notices, want 0", n)
+	}
+
+	clock.advance(2 * time.Hour) // 1h de retard
+	runOnce(t, s)
+	if n := notices(t, s, assigneeID, models.NotifOverdue, task.ID); n != 1 {
+		t.Fatalf("1h late: %d overdue notices, want 1", n)
+	}
+	if n := notices(t, s, ownerID, models.NotifEscalated, task.ID); n != 0 {
+		t.Fatalf("1h late: %d escalations, want 0", n)
+	}
+
+	clock.advance(46 * time.Hour) // 47h de retard
+	runOnce(t, s)
+	if n := notices(t, s, ownerID, models.NotifEscalated, task.ID); n != 0 {
+		t.Fatalf("47h late: %d escalations, want 0", n)
+	}
+
+	clock.advance(time.Hour) // 48h de retard
+	runOnce(t, s)
+	runOnce(t, s)
+	if n := notices(t, s, ownerID, models.NotifEscalated, task.ID); n != 1 {
+		t.Fatalf("48h late: %d escalations, want 1", n)
+	}
+	if n := notices(t, s, assigneeID, models.NotifOverdue, task.ID); n != 1 {
+		t.Fatalf("overdue notice repeated: %d, want 1", n)
+	}
+}
+
+func TestEscalationDisabled(t *testing.T) {
+	s, clock := newTestScheduler(t)
+	s.EscalateAfter = 0
+	task := addTask(t, s, -time.Hour, models.TaskStatusTodo)
+
+	clock.advance(1000 * time.Hour)
+	runOnce(t, s)
+	if n := notices(t, s, ownerID, models.NotifEscalated, task.ID); n != 0 {
+		t.Fatalf("escalation off: %d escalations, want 0", n)
+	}
+}
+
+func TestLeaseRenewedWithFrozenClock(t *testing.T) {
+	s, clock := newTestScheduler(t)
+	for i := 0; i < 3; i++ {
+		leader, err := s.acquire(clock.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !leader {
+			t.Fatalf("run %d: the holder lost its own lease", i)
+		}
+	}
+}
+
+func TestLeaseTakeover(t *testing.T) {
+	a, clock := newTestScheduler(t)
+	b := &Scheduler{DB: a.DB, Clock: clock, Lease: a.Lease, holder: "test-b"}
+
+	if leader, err := a.acquire(clock.Now()); err != nil || !leader {
+		t.Fatalf("a: leader=%v err=%v, want the lease", leader, err)
+	}
+	if leader, err := b.acquire(clock.Now()); err != nil || leader {
+		t.Fatalf("b while a holds the lease: leader=%v err=%v", leader, err)
+	}
+
+	// a ne renouvelle plus : b prend la main à l'expiration
+	clock.advance(a.Lease + time.Second)
+	if leader, err := b.acquire(clock.Now()); err != nil || !leader {
+		t.Fatalf("b after expiry: leader=%v err=%v, want the lease", leader, err)
+	}
+	if leader, err := a.acquire(clock.Now()); err != nil || leader {
+		t.Fatalf("a after the takeover: leader=%v err=%v", leader, err)
+	}
+}
+
+func TestFollowerDoesNothing(t *testing.T) {
+	a, clock := newTestScheduler(t)
+	b := &Scheduler{DB: a.DB, Clock: clock, RemindBefore: a.RemindBefore, Lease: a.Lease, holder: "test-b"}
+	task := addTask(t, a, time.Hour, models.TaskStatusTodo)
+
+	if leader, err := a.acquire(clock.Now()); err != nil || !leader {
+		t.Fatalf("a: leader=%v err=%v", leader, err)
+	}
+	if err := b.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if n := notices(t, a, assigneeID, models.NotifDueSoon, task.ID); n != 0 {
+		t.Fatalf("follower sent %d reminders, want 0", n)
+	}
+}
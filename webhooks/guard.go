@@ -0,0 +1,88 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for receivers on a loopback, private or
+// link-local address, unless WEBHOOK_ALLOW_PRIVATE=true (local testing)
+var ErrBlockedAddress = errors.New("webhook receivers on private or local addresses are not allowed")
+
+// 100.64.0.0/10 (CGNAT) : pas couvert par net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func allowPrivate() bool {
+	return os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true"
+}
+
+// blockedIP tells whether ip is not a public unicast address
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() || sharedAddressSpace.Contains(ip)
+}
+
+// CheckURL validates a receiver URL when it is saved: absolute http(s), and
+// not a local address when the host is an IP or localhost. Host names are
+// checked again on every connection, once resolved.
+func CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	if allowPrivate() {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && blockedIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// guardedDialer refuses connections to blocked addresses. The check runs on
+// the resolved address, so a public name pointing to 127.0.0.1 fails too.
+var guardedDialer = &net.Dialer{
+	Timeout: 5 * time.Second,
+	Control: func(network, address string, _ syscall.RawConn) error {
+		if allowPrivate() {
+			return nil
+		}
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+			return ErrBlockedAddress
+		}
+		return nil
+	},
+}
+
+// client sends the deliveries. No proxy (it would hide the receiver's
+// address from the dialer) and no redirects: a 3xx is a failed attempt.
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return guardedDialer.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        20,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
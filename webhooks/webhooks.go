@@ -0,0 +1,252 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/initializers"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/models"
+	"github.com/ENISSAY39/FP_GO_APP_Task_Manger_GHARBI_YASSINE_NAMAN_KUMAR/realtime"
+)
+
+const (
+	// MaxAttempts per delivery before it is marked FAILED
+	MaxAttempts = 8
+	// DisableAfter consecutive failed attempts the webhook is deactivated
+	DisableAfter = 20
+
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+	// lease taken on a delivery while it is being sent, so that two server
+	// instances never send the same row
+	claimLease = 2 * time.Minute
+	batchSize  = 20
+)
+
+// payload is the JSON body POSTed to the webhook URL
+type payload struct {
+	Event     string      `json:"event"`
+	EventID   uint64      `json:"event_id"`
+	ProjectID uint        `json:"project_id"`
+	ActorID   uint        `json:"actor_id"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign computes the X-Webhook-Signature header: HMAC-SHA256 of
+// "<timestamp>.<body>" with the webhook secret, hex encoded
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Subscribed reports whether a comma separated event list contains typ
+func Subscribed(events string, typ string) bool {
+	for _, e := range strings.Split(events, ",") {
+		e = strings.TrimSpace(e)
+		if e == "*" || e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Register queues a delivery for every active webhook subscribed to an event
+func Register(hub *realtime.Hub) {
+	hub.Listen(enqueue)
+}
+
+func enqueue(ev realtime.Event) {
+	var hooks []models.Webhook
+	if err := initializers.DB.Where("project_id = ? AND active = ?", ev.ProjectID, true).Find(&hooks).Error; err != nil {
+		log.Printf("webhooks: could not load webhooks of project %d: %v", ev.ProjectID, err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(payload{
+		Event:     ev.Type,
+		EventID:   ev.ID,
+		ProjectID: ev.ProjectID,
+		ActorID:   ev.ActorID,
+		Data:      ev.Data,
+		CreatedAt: ev.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("webhooks: could not encode event %d: %v", ev.ID, err)
+		return
+	}
+
+	for _, h := range hooks {
+		if !Subscribed(h.Events, ev.Type) {
+			continue
+		}
+		d := models.WebhookDelivery{
+			WebhookID:     h.ID,
+			EventType:     ev.Type,
+			Payload:       string(body),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := initializers.DB.Create(&d).Error; err != nil {
+			log.Printf("webhooks: could not queue delivery for webhook %d: %v", h.ID, err)
+		}
+	}
+}
+
+// Redeliver queues a copy of a past delivery; the original stays in the log
+func Redeliver(d models.WebhookDelivery) (models.WebhookDelivery, error) {
+	again := models.WebhookDelivery{
+		WebhookID:     d.WebhookID,
+		EventType:     d.EventType,
+		Payload:       d.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	err := initializers.DB.Create(&again).Error
+	return again, err
+}
+
+// backoff returns the delay before the next attempt (30s, 1m, 2m, ... 1h)
+func backoff(attempts int) time.Duration {
+	d := retryBase
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= retryMax {
+			return retryMax
+		}
+	}
+	return d
+}
+
+// StartWorker sends due deliveries in the background every interval
+func StartWorker(interval time.Duration) {
+	go func() {
+		for {
+			if err := ProcessDue(); err != nil {
+				log.Printf("webhooks: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// ProcessDue sends the pending deliveries whose time has come
+func ProcessDue() error {
+	var due []models.WebhookDelivery
+	if err := initializers.DB.
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Where("webhook_id IN (SELECT id FROM webhooks WHERE active = ? AND deleted_at IS NULL)", true).
+		Order("next_attempt_at").
+		Limit(batchSize).
+		Find(&due).Error; err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		// claim the row: only one instance wins the update
+		res := initializers.DB.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", d.ID, models.DeliveryPending, d.NextAttemptAt).
+			Update("next_attempt_at", time.Now().Add(claimLease))
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+
+		var hook models.Webhook
+		if err := initializers.DB.First(&hook, d.WebhookID).Error; err != nil {
+			continue
+		}
+		deliver(hook, d)
+	}
+	return nil
+}
+
+// deliver makes one attempt and records its outcome
+func deliver(hook models.Webhook, d models.WebhookDelivery) {
+	code, err := send(hook, d)
+	now := time.Now()
+	d.Attempts++
+	d.ResponseCode = code
+
+	if err == nil {
+		d.Status = models.DeliverySuccess
+		d.LastError = ""
+		d.DeliveredAt = &now
+		initializers.DB.Save(&d)
+		initializers.DB.Model(&models.Webhook{}).Where("id = ?", hook.ID).Update("consecutive_failures", 0)
+		return
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= MaxAttempts {
+		d.Status = models.DeliveryFailed
+	} else {
+		d.NextAttemptAt = now.Add(backoff(d.Attempts))
+	}
+	initializers.DB.Save(&d)
+
+	initializers.DB.Model(&models.Webhook{}).Where("id = ?", hook.ID).
+		Update("consecutive_failures", gorm.Expr("consecutive_failures + 1"))
+	res := initializers.DB.Model(&models.Webhook{}).
+		Where("id = ? AND active = ? AND consecutive_failures >= ?", hook.ID, true, DisableAfter).
+		Updates(map[string]interface{}{"active": false, "disabled_at": now})
+	if res.RowsAffected > 0 {
+		log.Printf("webhooks: webhook %d disabled after %d consecutive failures", hook.ID, DisableAfter)
+	}
+}
+
+// send POSTs the payload; any non-2xx status is an error
+func send(hook models.Webhook, d models.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	ts := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TaskManager-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(hook.Secret, ts, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}